@@ -0,0 +1,406 @@
+package availability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Utils "github.com/agitdevcenter/gopkg/utils"
+)
+
+const (
+	DefaultRefreshInterval = 5 * time.Second
+
+	ActionSet          = "set"
+	ActionAddWindow    = "add window"
+	ActionRemoveWindow = "remove window"
+)
+
+// DefaultMessage answered while unavailable when no message is set
+var DefaultMessage = http.StatusText(http.StatusServiceUnavailable)
+
+var (
+	ErrUnknownEndpoint = errors.New("endpoint has no availability toggle")
+	ErrUnknownWindow   = errors.New("unknown maintenance window")
+	ErrInvalidWindow   = errors.New("maintenance window must end after its start")
+	// ErrBackend wraps the errors of the backend loading or saving a change
+	ErrBackend = errors.New("availability backend error")
+)
+
+// State availability of the server or of an endpoint, Message is answered while unavailable
+type State struct {
+	Available bool      `json:"available"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// Window scheduled maintenance of the server or of the endpoint at Path, unavailable from Start to End
+type Window struct {
+	ID      string    `json:"id"`
+	Path    string    `json:"path,omitempty"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Message string    `json:"message,omitempty"`
+}
+
+func (w Window) active(now time.Time) bool {
+	return !now.Before(w.Start) && now.Before(w.End)
+}
+
+// Status state of the server, of the endpoints and the maintenance windows, stored by the backend
+type Status struct {
+	Server    State            `json:"server"`
+	Endpoints map[string]State `json:"endpoints"`
+	Windows   []Window         `json:"windows"`
+}
+
+func (s Status) copy() Status {
+	copied := Status{Server: s.Server, Endpoints: make(map[string]State, len(s.Endpoints))}
+	for path, state := range s.Endpoints {
+		copied.Endpoints[path] = state
+	}
+	copied.Windows = append([]Window{}, s.Windows...)
+	return copied
+}
+
+// Change of the availability of the server, or of the endpoint at Path
+type Change struct {
+	Path      string `json:"path"`
+	Available bool   `json:"available"`
+	Message   string `json:"message"`
+}
+
+// Actor who changed the availability, recorded in the audit events
+type Actor struct {
+	User   string
+	Source string
+}
+
+// Event audit of a change
+type Event struct {
+	Action    string
+	Path      string
+	Available bool
+	Message   string
+	Window    *Window
+	Actor     Actor
+	Time      time.Time
+}
+
+// Availability state shared by the replicas through the backend, safe for concurrent use. Check answers
+// from a local copy refreshed from the backend every refresh interval, changes are written to the backend first.
+type Availability struct {
+	logger          Logger.Logger
+	backend         Backend
+	endpoints       []string
+	windows         []Window
+	refreshInterval time.Duration
+	audit           func(Event)
+	trustedProxies  []*net.IPNet
+	now             func() time.Time
+
+	mutex       sync.RWMutex
+	status      Status
+	refreshedAt time.Time
+	refreshing  int32
+	// serializes the changes of this process, the backend is last writer wins between replicas
+	writeMutex sync.Mutex
+}
+
+func New(opts []Option) *Availability {
+	a := &Availability{
+		refreshInterval: DefaultRefreshInterval,
+		now:             time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.logger == nil {
+		a.logger = Logger.Noop()
+	}
+
+	if a.backend == nil {
+		a.backend = NewMemory()
+	}
+
+	a.status = Status{
+		Server:    State{Available: true},
+		Endpoints: make(map[string]State),
+		Windows:   append([]Window{}, a.windows...),
+	}
+	for _, path := range a.endpoints {
+		a.status.Endpoints[path] = State{Available: true}
+	}
+
+	return a
+}
+
+func (a *Availability) SetLogger(logger Logger.Logger) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.logger = logger
+}
+
+// SetEndpoints endpoints having a toggle, states of the endpoints kept are not changed
+func (a *Availability) SetEndpoints(paths []string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.endpoints = paths
+	a.status = a.merge(a.status)
+}
+
+// merge keeps the states of the configured endpoints only, missing ones are available. Must be called with the lock held.
+func (a *Availability) merge(status Status) Status {
+	merged := status.copy()
+	merged.Endpoints = make(map[string]State, len(a.endpoints))
+	for _, path := range a.endpoints {
+		state, ok := status.Endpoints[path]
+		if !ok {
+			state = State{Available: true}
+		}
+		merged.Endpoints[path] = state
+	}
+	return merged
+}
+
+// Status copy of the current status
+func (a *Availability) Status() Status {
+	a.refreshIfStale()
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.status.copy()
+}
+
+// Check whether the request path is available, the message is answered while unavailable.
+// Maintenance windows come first, then the server and the endpoints matched by prefix.
+func (a *Availability) Check(path string) (available bool, message string) {
+	a.refreshIfStale()
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	now := a.now()
+	for _, window := range a.status.Windows {
+		if window.active(now) && (len(window.Path) == 0 || strings.HasPrefix(path, window.Path)) {
+			return false, messageOf(window.Message)
+		}
+	}
+
+	if !a.status.Server.Available {
+		return false, messageOf(a.status.Server.Message)
+	}
+
+	for endpoint, state := range a.status.Endpoints {
+		if !state.Available && strings.HasPrefix(path, endpoint) {
+			return false, messageOf(state.Message)
+		}
+	}
+
+	return true, ""
+}
+
+func messageOf(message string) string {
+	if len(message) == 0 {
+		return DefaultMessage
+	}
+	return message
+}
+
+// Refresh reads the status of the backend, nothing is changed when the backend has no status yet
+func (a *Availability) Refresh(ctx context.Context) error {
+	status, err := a.backend.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("loading availability : %+v", err)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.refreshedAt = a.now()
+	if status != nil {
+		a.status = a.merge(*status)
+	}
+	return nil
+}
+
+// refreshIfStale refreshes in background when the local copy is older than the refresh interval
+func (a *Availability) refreshIfStale() {
+	if _, ok := a.backend.(*memory); ok {
+		return
+	}
+
+	a.mutex.RLock()
+	stale := a.now().Sub(a.refreshedAt) >= a.refreshInterval
+	logger := a.logger
+	a.mutex.RUnlock()
+
+	if !stale || !atomic.CompareAndSwapInt32(&a.refreshing, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&a.refreshing, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), a.refreshInterval)
+		defer cancel()
+
+		if err := a.Refresh(ctx); err != nil {
+			logger.Error(err.Error())
+		}
+	}()
+}
+
+// update applies change to the status of the backend and saves it, expired windows are removed
+func (a *Availability) update(ctx context.Context, change func(status *Status) error) error {
+	a.writeMutex.Lock()
+	defer a.writeMutex.Unlock()
+
+	stored, err := a.backend.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("%w, loading availability : %+v", ErrBackend, err)
+	}
+
+	a.mutex.RLock()
+	status := a.status.copy()
+	if stored != nil {
+		status = a.merge(*stored)
+	}
+	a.mutex.RUnlock()
+
+	if err := change(&status); err != nil {
+		return err
+	}
+
+	now := a.now()
+	windows := status.Windows[:0]
+	for _, window := range status.Windows {
+		if window.End.After(now) {
+			windows = append(windows, window)
+		}
+	}
+	status.Windows = windows
+
+	if err := a.backend.Save(ctx, status); err != nil {
+		return fmt.Errorf("%w, saving availability : %+v", ErrBackend, err)
+	}
+
+	a.mutex.Lock()
+	a.status = status
+	a.refreshedAt = now
+	a.mutex.Unlock()
+
+	return nil
+}
+
+// Set the availability of the server, or of an endpoint given to WithEndpoints
+func (a *Availability) Set(ctx context.Context, change Change, actor Actor) error {
+	now := a.now()
+	state := State{Available: change.Available, Message: change.Message, UpdatedBy: actor.User, UpdatedAt: now}
+
+	err := a.update(ctx, func(status *Status) error {
+		if len(change.Path) == 0 {
+			status.Server = state
+			return nil
+		}
+
+		if _, ok := status.Endpoints[change.Path]; !ok {
+			return fmt.Errorf("%s : %w", change.Path, ErrUnknownEndpoint)
+		}
+		status.Endpoints[change.Path] = state
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	a.record(Event{Action: ActionSet, Path: change.Path, Available: change.Available, Message: change.Message, Actor: actor, Time: now})
+	return nil
+}
+
+// AddWindow schedules a maintenance window, its ID is generated when empty
+func (a *Availability) AddWindow(ctx context.Context, window Window, actor Actor) (Window, error) {
+	if !window.End.After(window.Start) {
+		return window, ErrInvalidWindow
+	}
+	if len(window.ID) == 0 {
+		window.ID = Utils.GenerateThreadId()
+	}
+
+	err := a.update(ctx, func(status *Status) error {
+		status.Windows = append(status.Windows, window)
+		sort.SliceStable(status.Windows, func(i, j int) bool {
+			return status.Windows[i].Start.Before(status.Windows[j].Start)
+		})
+		return nil
+	})
+	if err != nil {
+		return window, err
+	}
+
+	a.record(Event{Action: ActionAddWindow, Path: window.Path, Message: window.Message, Window: &window, Actor: actor, Time: a.now()})
+	return window, nil
+}
+
+func (a *Availability) RemoveWindow(ctx context.Context, id string, actor Actor) error {
+	var removed Window
+	err := a.update(ctx, func(status *Status) error {
+		for i, window := range status.Windows {
+			if window.ID == id {
+				removed = window
+				status.Windows = append(status.Windows[:i:i], status.Windows[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("%s : %w", id, ErrUnknownWindow)
+	})
+	if err != nil {
+		return err
+	}
+
+	a.record(Event{Action: ActionRemoveWindow, Path: removed.Path, Message: removed.Message, Window: &removed, Actor: actor, Time: a.now()})
+	return nil
+}
+
+// record logs the audit event and passes it to WithAudit
+func (a *Availability) record(event Event) {
+	a.mutex.RLock()
+	logger := a.logger
+	a.mutex.RUnlock()
+
+	path := event.Path
+	if len(path) == 0 {
+		path = "server"
+	}
+
+	switch event.Action {
+	case ActionSet:
+		logger.Info(fmt.Sprintf("availability of %s set to %v by %s from %s", path, event.Available, event.Actor.User, event.Actor.Source))
+	default:
+		logger.Info(fmt.Sprintf("availability %s %s of %s from %s to %s by %s from %s", event.Action, event.Window.ID, path,
+			event.Window.Start.Format(time.RFC3339), event.Window.End.Format(time.RFC3339), event.Actor.User, event.Actor.Source))
+	}
+
+	if a.audit != nil {
+		a.audit(event)
+	}
+}
+
+func (a *Availability) logError(message string) {
+	a.mutex.RLock()
+	logger := a.logger
+	a.mutex.RUnlock()
+	logger.Error(message)
+}
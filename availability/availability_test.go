@@ -0,0 +1,181 @@
+package availability
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// shared backend of the replicas, not skipped by the refresh like the memory backend
+type shared struct {
+	mutex  sync.Mutex
+	status *Status
+}
+
+func (s *shared) Load(ctx context.Context) (*Status, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.status == nil {
+		return nil, nil
+	}
+	status := s.status.copy()
+	return &status, nil
+}
+
+func (s *shared) Save(ctx context.Context, status Status) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status = &status
+	return nil
+}
+
+func TestCheck(t *testing.T) {
+	var events []Event
+	a := New([]Option{WithEndpoints([]string{"/payment"}), WithAudit(func(event Event) { events = append(events, event) })})
+	actor := Actor{User: "ops", Source: "10.0.0.1"}
+
+	available, _ := a.Check("/payment/transfer")
+	assert.True(t, available)
+
+	assert.NoError(t, a.Set(context.Background(), Change{Path: "/payment", Message: "payment is under maintenance"}, actor))
+	available, message := a.Check("/payment/transfer")
+	assert.False(t, available)
+	assert.Equal(t, "payment is under maintenance", message)
+
+	available, _ = a.Check("/account")
+	assert.True(t, available)
+
+	assert.Error(t, a.Set(context.Background(), Change{Path: "/account"}, actor))
+
+	assert.NoError(t, a.Set(context.Background(), Change{}, actor))
+	available, message = a.Check("/account")
+	assert.False(t, available)
+	assert.Equal(t, DefaultMessage, message)
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, "ops", a.Status().Server.UpdatedBy)
+}
+
+func TestWindow(t *testing.T) {
+	now := time.Date(2020, 3, 1, 22, 0, 0, 0, time.UTC)
+	a := New(nil)
+	a.now = func() time.Time { return now }
+
+	window, err := a.AddWindow(context.Background(), Window{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour), Message: "upgrade"}, Actor{User: "ops"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, window.ID)
+
+	available, _ := a.Check("/payment")
+	assert.True(t, available)
+
+	now = now.Add(90 * time.Minute)
+	available, message := a.Check("/payment")
+	assert.False(t, available)
+	assert.Equal(t, "upgrade", message)
+
+	assert.NoError(t, a.RemoveWindow(context.Background(), window.ID, Actor{User: "ops"}))
+	assert.True(t, errors.Is(a.RemoveWindow(context.Background(), window.ID, Actor{User: "ops"}), ErrUnknownWindow))
+
+	available, _ = a.Check("/payment")
+	assert.True(t, available)
+}
+
+func TestSharedBackend(t *testing.T) {
+	backend := &shared{}
+	first := New([]Option{WithBackend(backend), WithRefreshInterval(time.Millisecond)})
+	second := New([]Option{WithBackend(backend), WithRefreshInterval(time.Millisecond)})
+
+	assert.NoError(t, first.Set(context.Background(), Change{Message: "closed"}, Actor{User: "ops"}))
+
+	assert.NoError(t, second.Refresh(context.Background()))
+	available, message := second.Check("/payment")
+	assert.False(t, available)
+	assert.Equal(t, "closed", message)
+}
+
+func TestHandler(t *testing.T) {
+	a := New([]Option{WithEndpoints([]string{"/payment"})})
+
+	serve := func(handler http.Handler, method, body string, authorize func(r *http.Request)) int {
+		request := httptest.NewRequest(method, "/availability", strings.NewReader(body))
+		if authorize != nil {
+			authorize(request)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(a.Handler(nil), "GET", "", nil))
+	assert.Equal(t, http.StatusForbidden, serve(a.Handler(nil), "PUT", `{"available":false}`, nil))
+
+	handler := a.Handler(Any(BasicAuth("ops", "secret"), Token("token")))
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, "PUT", `{"available":false}`, nil))
+	assert.Equal(t, http.StatusOK, serve(handler, "PUT", `{"path":"/payment","available":false}`, func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer token")
+	}))
+	assert.Equal(t, http.StatusOK, serve(handler, "POST", `{"start":"2020-03-01T22:00:00Z","end":"2999-03-01T23:00:00Z"}`, func(r *http.Request) {
+		r.SetBasicAuth("ops", "secret")
+	}))
+	assert.Equal(t, http.StatusNotFound, serve(handler, "DELETE", "", func(r *http.Request) {
+		r.SetBasicAuth("ops", "secret")
+	}))
+
+	status := a.Status()
+	assert.False(t, status.Endpoints["/payment"].Available)
+	assert.Equal(t, "token", status.Endpoints["/payment"].UpdatedBy)
+	assert.Len(t, status.Windows, 1)
+}
+
+// failing backend, its errors describe the connection
+type failing struct{}
+
+func (failing) Load(ctx context.Context) (*Status, error) {
+	return nil, errors.New("dial tcp consul.internal:8500 : connection refused")
+}
+
+func (failing) Save(ctx context.Context, status Status) error {
+	return nil
+}
+
+func TestHandlerErrors(t *testing.T) {
+	serve := func(a *Availability, method, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, "/availability", strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer token")
+		recorder := httptest.NewRecorder()
+		a.Handler(Token("token")).ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	a := New([]Option{WithEndpoints([]string{"/payment"})})
+	assert.Equal(t, http.StatusBadRequest, serve(a, "PUT", `{"available":`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(a, "PUT", `{"path":"/unknown","available":false}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(a, "POST", `{"start":"2999-03-01T23:00:00Z","end":"2999-03-01T22:00:00Z"}`).Code)
+
+	recorder := serve(New([]Option{WithBackend(failing{})}), "PUT", `{"available":false}`)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "consul.internal")
+}
+
+func TestSource(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	request := func(remote string) *http.Request {
+		r := httptest.NewRequest("GET", "/availability", nil)
+		r.RemoteAddr = remote
+		r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+		return r
+	}
+
+	assert.Equal(t, "192.0.2.1", New(nil).source(request("192.0.2.1:4000")))
+	a := New([]Option{WithTrustedProxies(proxies)})
+	assert.Equal(t, "192.0.2.1", a.source(request("192.0.2.1:4000")))
+	assert.Equal(t, "203.0.113.7", a.source(request("10.0.0.2:4000")))
+}
@@ -0,0 +1,123 @@
+package availability
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/agitdevcenter/gopkg/cache"
+	"github.com/hashicorp/consul/api"
+)
+
+// DefaultKey key of the status in the shared backends
+const DefaultKey = "availability"
+
+// Backend storage of the status, Load returns nil when nothing is stored yet
+type Backend interface {
+	Load(ctx context.Context) (*Status, error)
+	Save(ctx context.Context, status Status) error
+}
+
+type memory struct {
+	mutex  sync.RWMutex
+	status *Status
+}
+
+// NewMemory status of this process only
+func NewMemory() Backend {
+	return &memory{}
+}
+
+func (m *memory) Load(ctx context.Context) (*Status, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.status == nil {
+		return nil, nil
+	}
+	status := m.status.copy()
+	return &status, nil
+}
+
+func (m *memory) Save(ctx context.Context, status Status) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	copied := status.copy()
+	m.status = &copied
+	return nil
+}
+
+func decode(value []byte) (*Status, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	var status Status
+	if err := json.Unmarshal(value, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+type keyval struct {
+	keyval cache.Keyval
+	key    string
+}
+
+// NewKeyval status stored in redis or memcached at key without expiration, default key is DefaultKey
+func NewKeyval(kv cache.Keyval, key string) Backend {
+	if len(key) == 0 {
+		key = DefaultKey
+	}
+	return &keyval{keyval: kv, key: key}
+}
+
+func (k *keyval) Load(ctx context.Context) (*Status, error) {
+	value, err := k.keyval.Get(k.key)
+	if err != nil {
+		return nil, err
+	}
+	return decode(value)
+}
+
+func (k *keyval) Save(ctx context.Context, status Status) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return k.keyval.Set(k.key, value, 0)
+}
+
+type consul struct {
+	kv  *api.KV
+	key string
+}
+
+// NewConsul status stored in the Consul KV at key, default key is DefaultKey
+func NewConsul(kv *api.KV, key string) Backend {
+	if len(key) == 0 {
+		key = DefaultKey
+	}
+	return &consul{kv: kv, key: key}
+}
+
+func (c *consul) Load(ctx context.Context) (*Status, error) {
+	pair, _, err := c.kv.Get(c.key, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, nil
+	}
+	return decode(pair.Value)
+}
+
+func (c *consul) Save(ctx context.Context, status Status) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	_, err = c.kv.Put(&api.KVPair{Key: c.key, Value: value}, (&api.WriteOptions{}).WithContext(ctx))
+	return err
+}
@@ -0,0 +1,154 @@
+package availability
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	Response "github.com/agitdevcenter/gopkg/response"
+)
+
+// Authenticator returns the user of an authorized request
+type Authenticator func(r *http.Request) (user string, ok bool)
+
+func BasicAuth(username, password string) Authenticator {
+	return func(r *http.Request) (string, bool) {
+		user, pass, ok := r.BasicAuth()
+		if ok && equal(user, username) && equal(pass, password) {
+			return user, true
+		}
+		return "", false
+	}
+}
+
+// Token bearer token of the Authorization header, the user is named "token"
+func Token(token string) Authenticator {
+	return func(r *http.Request) (string, bool) {
+		authorization := r.Header.Get("Authorization")
+		if strings.HasPrefix(authorization, "Bearer ") && equal(strings.TrimPrefix(authorization, "Bearer "), token) {
+			return "token", true
+		}
+		return "", false
+	}
+}
+
+// Any authorizes the requests authorized by one of the authenticators
+func Any(authenticators ...Authenticator) Authenticator {
+	return func(r *http.Request) (string, bool) {
+		for _, authenticate := range authenticators {
+			if user, ok := authenticate(r); ok {
+				return user, true
+			}
+		}
+		return "", false
+	}
+}
+
+func equal(value, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(value), []byte(expected)) == 1
+}
+
+// Handler GET answers the status, PUT a Change sets a state, POST a Window schedules a maintenance
+// and DELETE ?id= removes it. Changes are forbidden without authenticator.
+func (a *Availability) Handler(authenticate Authenticator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			respond(w, http.StatusOK, Response.SuccessCode, http.StatusText(http.StatusOK), a.Status())
+			return
+		}
+
+		if authenticate == nil {
+			respond(w, http.StatusForbidden, Response.GeneralError, "availability changes are not allowed on this URL", nil)
+			return
+		}
+		user, ok := authenticate(r)
+		if !ok {
+			respond(w, http.StatusUnauthorized, Response.GeneralError, http.StatusText(http.StatusUnauthorized), nil)
+			return
+		}
+		actor := Actor{User: user, Source: a.source(r)}
+
+		var err error
+		switch r.Method {
+		case http.MethodPut:
+			var change Change
+			if err = json.NewDecoder(r.Body).Decode(&change); err != nil {
+				respond(w, http.StatusBadRequest, Response.GeneralError, err.Error(), nil)
+				return
+			}
+			err = a.Set(r.Context(), change, actor)
+		case http.MethodPost:
+			var window Window
+			if err = json.NewDecoder(r.Body).Decode(&window); err != nil {
+				respond(w, http.StatusBadRequest, Response.GeneralError, err.Error(), nil)
+				return
+			}
+			_, err = a.AddWindow(r.Context(), window, actor)
+		case http.MethodDelete:
+			err = a.RemoveWindow(r.Context(), r.URL.Query().Get("id"), actor)
+		default:
+			respond(w, http.StatusMethodNotAllowed, Response.GeneralError, http.StatusText(http.StatusMethodNotAllowed), nil)
+			return
+		}
+
+		// backend errors are only logged, they may describe its address or credentials
+		switch {
+		case err == nil:
+			respond(w, http.StatusOK, Response.SuccessCode, http.StatusText(http.StatusOK), a.Status())
+		case errors.Is(err, ErrUnknownWindow):
+			respond(w, http.StatusNotFound, Response.GeneralError, err.Error(), nil)
+		case errors.Is(err, ErrUnknownEndpoint), errors.Is(err, ErrInvalidWindow):
+			respond(w, http.StatusBadRequest, Response.GeneralError, err.Error(), nil)
+		case errors.Is(err, ErrBackend):
+			a.logError(fmt.Sprintf("availability change by %s from %s error : %+v", actor.User, actor.Source, err))
+			respond(w, http.StatusServiceUnavailable, Response.GeneralError, "availability backend unavailable", nil)
+		default:
+			a.logError(fmt.Sprintf("availability change by %s from %s error : %+v", actor.User, actor.Source, err))
+			respond(w, http.StatusInternalServerError, Response.GeneralError, http.StatusText(http.StatusInternalServerError), nil)
+		}
+	})
+}
+
+func respond(w http.ResponseWriter, code int, status, message string, data interface{}) {
+	if data == nil {
+		data = struct{}{}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(Response.CreateResponse(status, message, data))
+}
+
+// source address of the client, the proxy headers are read for requests of WithTrustedProxies only
+func (a *Availability) source(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remote = host
+	}
+
+	if !a.trusted(net.ParseIP(remote)) {
+		return remote
+	}
+	if ip := r.Header.Get("X-Real-IP"); len(ip) > 0 {
+		return ip
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); len(forwarded) > 0 {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return remote
+}
+
+func (a *Availability) trusted(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range a.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
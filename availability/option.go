@@ -0,0 +1,61 @@
+package availability
+
+import (
+	"net"
+	"time"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+)
+
+type Option func(*Availability)
+
+func WithLogger(logger Logger.Logger) Option {
+	return func(a *Availability) {
+		a.logger = logger
+	}
+}
+
+// WithBackend status shared by the replicas, ex: NewKeyval or NewConsul, default is NewMemory
+func WithBackend(backend Backend) Option {
+	return func(a *Availability) {
+		a.backend = backend
+	}
+}
+
+// WithEndpoints path prefixes having their own toggle
+func WithEndpoints(paths []string) Option {
+	return func(a *Availability) {
+		a.endpoints = paths
+	}
+}
+
+// WithWindows maintenance windows scheduled until the backend has a status
+func WithWindows(windows ...Window) Option {
+	return func(a *Availability) {
+		a.windows = append(a.windows, windows...)
+	}
+}
+
+// WithRefreshInterval maximum age of the local copy of the backend status, default is DefaultRefreshInterval
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(a *Availability) {
+		if interval > 0 {
+			a.refreshInterval = interval
+		}
+	}
+}
+
+// WithTrustedProxies the source of the audit is taken from X-Real-IP or X-Forwarded-For for requests of
+// these networks only, the remote address otherwise
+func WithTrustedProxies(networks ...*net.IPNet) Option {
+	return func(a *Availability) {
+		a.trustedProxies = append(a.trustedProxies, networks...)
+	}
+}
+
+// WithAudit receives every change after it is logged, ex: to store the audit trail
+func WithAudit(audit func(Event)) Option {
+	return func(a *Availability) {
+		a.audit = audit
+	}
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"context"
 	"time"
 
 	"github.com/agitdevcenter/gopkg/logger"
@@ -16,6 +17,21 @@ type Keyval interface {
 	Incr(key string) ([]byte, error)
 }
 
+// ping runs fn until ctx is done, the clients ping without a context
+func ping(ctx context.Context, fn func() error) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- fn()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 //Topology Server topology usually for HA setup
 type Topology int
 
@@ -58,6 +74,7 @@ type Mock struct {
 	StubAdd    func() error
 	StubDelete func() error
 	StubIncr   func() ([]byte, error)
+	StubCheck  func() error
 }
 
 //SetLogger mocker
@@ -77,3 +94,11 @@ func (m *Mock) Get(key string) ([]byte, error) { return m.StubGet() }
 
 // Incr mocker
 func (m *Mock) Incr(key string) ([]byte, error) { return m.StubIncr() }
+
+// Check mocker, healthy without StubCheck
+func (m *Mock) Check(ctx context.Context) error {
+	if m.StubCheck == nil {
+		return nil
+	}
+	return m.StubCheck()
+}
@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	failed := errors.New("connection refused")
+	assert.Equal(t, failed, ping(context.Background(), func() error { return failed }))
+
+	// a hanging ping is abandoned when the check times out
+	release := make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(ctx, func() error {
+		<-release
+		return nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
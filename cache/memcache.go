@@ -1,13 +1,17 @@
 package cache
 
 import (
+	"context"
 	"time"
 
+	"github.com/agitdevcenter/gopkg/health"
 	"github.com/agitdevcenter/gopkg/logger"
 	"github.com/bradfitz/gomemcache/memcache"
 	"go.uber.org/zap"
 )
 
+var _ health.Checker = (*mcache)(nil)
+
 type mcache struct {
 	conn   *memcache.Client
 	logger logger.Logger
@@ -126,3 +130,8 @@ func (m *mcache) Delete(key string) error {
 func (m *mcache) Incr(key string) (rcv []byte, err error) {
 	return
 }
+
+// Check pings every server until ctx is done, see health.Checker
+func (m *mcache) Check(ctx context.Context) error {
+	return ping(ctx, m.conn.Ping)
+}
@@ -1,14 +1,18 @@
 package cache
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/agitdevcenter/gopkg/health"
 	"github.com/agitdevcenter/gopkg/logger"
 	"github.com/mediocregopher/radix/v3"
 )
 
+var _ health.Checker = (*rcache)(nil)
+
 type rcache struct {
 	client       radix.Client
 	sentinelConn *radix.Sentinel
@@ -189,3 +193,10 @@ func (m *rcache) Incr(key string) (rcv []byte, err error) {
 	}
 	return
 }
+
+// Check pings the server until ctx is done, see health.Checker
+func (m *rcache) Check(ctx context.Context) error {
+	return ping(ctx, func() error {
+		return m.client.Do(radix.Cmd(nil, "PING"))
+	})
+}
@@ -0,0 +1,54 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync"
+)
+
+// Keypair TLS certificate loaded from files that can be reloaded while serving,
+// use GetCertificate in tls.Config so new handshakes use the reloaded certificate
+type Keypair struct {
+	certificateFile string
+	keyFile         string
+	mutex           sync.RWMutex
+	certificate     *tls.Certificate
+}
+
+// NewKeypair loads the certificate and key files
+func NewKeypair(certificateFile, keyFile string) (*Keypair, error) {
+	k := &Keypair{
+		certificateFile: certificateFile,
+		keyFile:         keyFile,
+	}
+
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Reload reads the files again, the current certificate is kept when they are invalid
+func (k *Keypair) Reload() error {
+	certificate, err := tls.LoadX509KeyPair(k.certificateFile, k.keyFile)
+	if err != nil {
+		return fmt.Errorf("could not load certificate %s : %+v", k.certificateFile, err)
+	}
+
+	k.mutex.Lock()
+	k.certificate = &certificate
+	k.mutex.Unlock()
+
+	return nil
+}
+
+func (k *Keypair) Certificate() *tls.Certificate {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.certificate
+}
+
+func (k *Keypair) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return k.Certificate(), nil
+}
@@ -0,0 +1,69 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeKeypair(t *testing.T, dir, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "tls.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "tls.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
+
+func commonName(t *testing.T, k *Keypair) string {
+	certificate, err := k.GetCertificate(nil)
+	assert.NoError(t, err)
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestKeypairReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certificate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeKeypair(t, dir, "first")
+	k, err := NewKeypair(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	assert.NoError(t, err)
+	assert.Equal(t, "first", commonName(t, k))
+
+	writeKeypair(t, dir, "second")
+	assert.NoError(t, k.Reload())
+	assert.Equal(t, "second", commonName(t, k))
+
+	// a broken rotation keeps serving the previous certificate
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "tls.key"), []byte("broken"), 0600))
+	assert.Error(t, k.Reload())
+	assert.Equal(t, "second", commonName(t, k))
+
+	_, err = NewKeypair(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "tls.key"))
+	assert.Error(t, err)
+}
@@ -60,6 +60,16 @@ func (conn *Mock) SetContext(c context.Context) {}
 //WithTimeout implementation
 func (conn *Mock) WithTimeout(timeSec time.Duration) context.CancelFunc { return func() {} }
 
+//Check mock func, returns the error of the Check stub
+func (conn *Mock) Check(ctx context.Context) error {
+	for _, v := range conn.stubs {
+		if conn.stubMatchCaller(v) {
+			return v.Error
+		}
+	}
+	return nil
+}
+
 func (conn *Mock) getCaller(level int) string {
 	var callerFunc string
 	pc, _, _, ok := runtime.Caller(level)
@@ -10,6 +10,7 @@ import (
 	"github.com/agitdevcenter/gopkg/logger"
 	mo "go.mongodb.org/mongo-driver/mongo"
 	moOpts "go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type (
@@ -25,6 +26,7 @@ type (
 		GetContext() context.Context
 		SetContext(c context.Context)
 		WithTimeout(timeSec time.Duration) context.CancelFunc
+		Check(ctx context.Context) error
 
 		//Find(filter interface{}, outputVal interface{}, opts ...*Options) (results []interface{}, err error)
 		Find(filter interface{}, outputVal interface{}, opts ...*Options) (err error)
@@ -89,6 +91,11 @@ func (conn *connection) Disconnect() (err error) {
 	return
 }
 
+//Check ping the primary, see health.Checker
+func (conn *connection) Check(ctx context.Context) error {
+	return conn.client.Ping(ctx, readpref.Primary())
+}
+
 //GetContext get connection context
 func (conn *connection) GetContext() context.Context {
 	return conn.ctx
@@ -1,6 +1,10 @@
 package mongo
 
-import "time"
+import (
+	"time"
+
+	"github.com/agitdevcenter/gopkg/pagination"
+)
 
 //Options options for find
 type Options struct {
@@ -21,3 +25,23 @@ type ClientOptions struct {
 	MinPoolSize     uint64
 	MaxConnIdleTime time.Duration
 }
+
+//ApplyPage sets Skip, Limit and Sort from page, keyset pages also need their filter from PageFilter
+func (opts *Options) ApplyPage(page pagination.Page) *Options {
+	opts.Skip = page.MongoSkip()
+	opts.Limit = int64(page.Limit)
+	if sort := page.MongoSort(); sort != nil {
+		opts.Sort = sort
+	}
+	return opts
+}
+
+//PageOptions options for one page
+func PageOptions(page pagination.Page) *Options {
+	return new(Options).ApplyPage(page)
+}
+
+//PageFilter adds the keyset condition of page to filter
+func PageFilter(filter interface{}, page pagination.Page) interface{} {
+	return page.MongoFilter(filter)
+}
@@ -25,6 +25,11 @@ func (db *Client) WithContext(ctx context.Context) *Client {
 	}
 }
 
+// Check pings the database, see health.Checker
+func (db *Client) Check(ctx context.Context) error {
+	return db.DB.PingContext(ctx)
+}
+
 // Query queries the database and returns an *sql.Rows.
 func (db *Client) Query(query string, args ...interface{}) (*sql.Rows, error) {
 
@@ -0,0 +1,23 @@
+package mysql
+
+import (
+	"github.com/agitdevcenter/gopkg/pagination"
+	"github.com/jmoiron/sqlx"
+)
+
+// PageQuery wraps query so it returns only the rows of page, see pagination.Page.SQL
+func PageQuery(query string, page pagination.Page, args ...interface{}) (string, []interface{}) {
+	return page.SQL(query, sqlx.QUESTION, args...)
+}
+
+// SelectPage runs query limited to page into dest.
+func (db *Client) SelectPage(dest interface{}, page pagination.Page, query string, args ...interface{}) error {
+	query, args = PageQuery(query, page, args...)
+	return db.Select(dest, query, args...)
+}
+
+// Count returns the number of rows of query, the total of a page.
+func (db *Client) Count(query string, args ...interface{}) (total int64, err error) {
+	err = db.Get(&total, pagination.CountSQL(query), args...)
+	return
+}
@@ -25,6 +25,11 @@ func (db *SQLDB) WithContext(ctx context.Context) *SQLDB {
 	}
 }
 
+// Check pings the database, see health.Checker
+func (db *SQLDB) Check(ctx context.Context) error {
+	return db.DB.PingContext(ctx)
+}
+
 // Query queries the database and returns an *sql.Rows.
 func (db *SQLDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
 
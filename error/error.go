@@ -1,9 +1,11 @@
 package error
 
 import (
+	"errors"
+	"os"
+
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"os"
 )
 
 func New(errorCode string, message string) error {
@@ -13,15 +15,171 @@ func New(errorCode string, message string) error {
 	}
 }
 
+// NewWithDetails application error carrying details rendered as the response data, ex: field validation errors
+func NewWithDetails(errorCode string, message string, details interface{}) error {
+	return &ApplicationError{
+		ErrorCode: errorCode,
+		Message:   message,
+		Details:   details,
+	}
+}
+
+// Wrap application error caused by err, nil when err is nil
+func Wrap(err error, errorCode string, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &ApplicationError{
+		ErrorCode: errorCode,
+		Message:   message,
+		cause:     err,
+	}
+}
+
+// ApplicationError HTTPStatus, GRPCCode and Retryable left empty are resolved from the registered Definition of ErrorCode
 type ApplicationError struct {
-	ErrorCode string
-	Message   string
+	ErrorCode  string
+	Message    string
+	Details    interface{}
+	HTTPStatus int
+	GRPCCode   codes.Code
+	Retryable  bool
+	Metadata   map[string]string
+
+	cause error
 }
 
 func (e *ApplicationError) Error() string {
 	return e.Message
 }
 
+// Unwrap cause for errors.Is and errors.As
+func (e *ApplicationError) Unwrap() error {
+	return e.cause
+}
+
+// Cause for github.com/pkg/errors
+func (e *ApplicationError) Cause() error {
+	return e.cause
+}
+
+// Is matches application errors with the same code, errors.Is(err, ErrNotFound) holds for any wrapped ErrNotFound
+func (e *ApplicationError) Is(target error) bool {
+	t, ok := target.(*ApplicationError)
+	return ok && len(t.ErrorCode) > 0 && t.ErrorCode == e.ErrorCode
+}
+
+// Wrap copy of the error caused by err, use it on errors returned by Define
+func (e *ApplicationError) Wrap(err error) error {
+	wrapped := e.copy()
+	wrapped.cause = err
+	return wrapped
+}
+
+// WithMessage copy of the error with another message
+func (e *ApplicationError) WithMessage(message string) *ApplicationError {
+	copied := e.copy()
+	copied.Message = message
+	return copied
+}
+
+// WithMetadata copy of the error with key set in its metadata
+func (e *ApplicationError) WithMetadata(key, value string) *ApplicationError {
+	copied := e.copy()
+	copied.Metadata = make(map[string]string, len(e.Metadata)+1)
+	for k, v := range e.Metadata {
+		copied.Metadata[k] = v
+	}
+	copied.Metadata[key] = value
+	return copied
+}
+
+// GRPCStatus lets grpc render the error with its code and details when it is returned as is
+func (e *ApplicationError) GRPCStatus() *status.Status {
+	return e.Status()
+}
+
+func (e *ApplicationError) copy() *ApplicationError {
+	copied := *e
+	return &copied
+}
+
+// As first application error in the chain of err
+func As(err error) (*ApplicationError, bool) {
+	var applicationError *ApplicationError
+	if errors.As(err, &applicationError) {
+		return applicationError, true
+	}
+	return nil, false
+}
+
+// HTTPStatus of the first application error in the chain of err, zero when it is not known
+func HTTPStatus(err error) int {
+	applicationError, ok := As(err)
+	if !ok {
+		return 0
+	}
+
+	if applicationError.HTTPStatus > 0 {
+		return applicationError.HTTPStatus
+	}
+
+	definition, ok := Lookup(applicationError.ErrorCode)
+	if ok && definition.HTTPStatus > 0 {
+		return definition.HTTPStatus
+	}
+
+	if applicationError.GRPCCode != codes.OK {
+		return httpStatusFromCode(applicationError.GRPCCode)
+	}
+	if ok && definition.GRPCCode != codes.OK {
+		return httpStatusFromCode(definition.GRPCCode)
+	}
+
+	return 0
+}
+
+// GRPCCode of the first application error in the chain of err, codes.Unknown when it is not known
+func GRPCCode(err error) codes.Code {
+	applicationError, ok := As(err)
+	if !ok {
+		return status.Code(err)
+	}
+
+	if applicationError.GRPCCode != codes.OK {
+		return applicationError.GRPCCode
+	}
+
+	definition, ok := Lookup(applicationError.ErrorCode)
+	if ok && definition.GRPCCode != codes.OK {
+		return definition.GRPCCode
+	}
+
+	if applicationError.HTTPStatus > 0 {
+		return codeFromHTTPStatus(applicationError.HTTPStatus)
+	}
+	if ok && definition.HTTPStatus > 0 {
+		return codeFromHTTPStatus(definition.HTTPStatus)
+	}
+
+	return codes.Unknown
+}
+
+// IsRetryable true when the application error or its definition is retryable, timeouts are always retryable
+func IsRetryable(err error) bool {
+	if applicationError, ok := As(err); ok {
+		if applicationError.Retryable {
+			return true
+		}
+		if definition, ok := Lookup(applicationError.ErrorCode); ok && definition.Retryable {
+			return true
+		}
+	}
+
+	return IsTimeout(err)
+}
+
 // handling timeout from http and g rpc
 func IsTimeout(err error) (timeout bool) {
 	timeout = os.IsTimeout(err)
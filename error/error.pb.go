@@ -0,0 +1,131 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: error/error.proto
+
+package error
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// StatusDetail application error carried in google.rpc.Status details
+type StatusDetail struct {
+	Code       string            `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message    string            `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Metadata   map[string]string `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Retryable  bool              `protobuf:"varint,4,opt,name=retryable,proto3" json:"retryable,omitempty"`
+	HttpStatus int32             `protobuf:"varint,5,opt,name=http_status,json=httpStatus,proto3" json:"http_status,omitempty"`
+	// JSON encoded ApplicationError.Details, ex: field validation errors
+	DetailsJson          []byte   `protobuf:"bytes,6,opt,name=details_json,json=detailsJson,proto3" json:"details_json,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatusDetail) Reset()         { *m = StatusDetail{} }
+func (m *StatusDetail) String() string { return proto.CompactTextString(m) }
+func (*StatusDetail) ProtoMessage()    {}
+func (*StatusDetail) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ccb419ee0b1c77bb, []int{0}
+}
+
+func (m *StatusDetail) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusDetail.Unmarshal(m, b)
+}
+func (m *StatusDetail) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusDetail.Marshal(b, m, deterministic)
+}
+func (m *StatusDetail) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusDetail.Merge(m, src)
+}
+func (m *StatusDetail) XXX_Size() int {
+	return xxx_messageInfo_StatusDetail.Size(m)
+}
+func (m *StatusDetail) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusDetail.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusDetail proto.InternalMessageInfo
+
+func (m *StatusDetail) GetCode() string {
+	if m != nil {
+		return m.Code
+	}
+	return ""
+}
+
+func (m *StatusDetail) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *StatusDetail) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *StatusDetail) GetRetryable() bool {
+	if m != nil {
+		return m.Retryable
+	}
+	return false
+}
+
+func (m *StatusDetail) GetHttpStatus() int32 {
+	if m != nil {
+		return m.HttpStatus
+	}
+	return 0
+}
+
+func (m *StatusDetail) GetDetailsJson() []byte {
+	if m != nil {
+		return m.DetailsJson
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*StatusDetail)(nil), "gopkg.error.StatusDetail")
+	proto.RegisterMapType((map[string]string)(nil), "gopkg.error.StatusDetail.MetadataEntry")
+}
+
+func init() { proto.RegisterFile("error/error.proto", fileDescriptor_ccb419ee0b1c77bb) }
+
+var fileDescriptor_ccb419ee0b1c77bb = []byte{
+	// 274 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x90, 0x4d, 0x4b, 0xf3, 0x40,
+	0x10, 0xc7, 0xd9, 0xbe, 0x3d, 0xed, 0x24, 0x0f, 0xe8, 0xe2, 0x61, 0x11, 0xc1, 0xe8, 0xc5, 0x20,
+	0xb2, 0x01, 0xbd, 0x88, 0xbd, 0xf9, 0x72, 0x11, 0xbc, 0xc4, 0x9b, 0x97, 0xb2, 0x49, 0x86, 0x34,
+	0x36, 0xc9, 0x86, 0xdd, 0x49, 0x21, 0xdf, 0xc6, 0x8f, 0x2a, 0xdd, 0xa4, 0x5a, 0x2f, 0xc3, 0xcc,
+	0x8f, 0xdd, 0xdf, 0xf0, 0x1f, 0x38, 0x46, 0x63, 0xb4, 0x89, 0x5c, 0x95, 0x8d, 0xd1, 0xa4, 0xb9,
+	0x97, 0xeb, 0x66, 0x93, 0x4b, 0x87, 0x2e, 0xbf, 0x46, 0xe0, 0xbf, 0x93, 0xa2, 0xd6, 0x3e, 0x23,
+	0xa9, 0xa2, 0xe4, 0x1c, 0x26, 0xa9, 0xce, 0x50, 0xb0, 0x80, 0x85, 0x8b, 0xd8, 0xf5, 0x5c, 0xc0,
+	0xbf, 0x0a, 0xad, 0x55, 0x39, 0x8a, 0x91, 0xc3, 0xfb, 0x91, 0x3f, 0xc1, 0xbc, 0x42, 0x52, 0x99,
+	0x22, 0x25, 0xc6, 0xc1, 0x38, 0xf4, 0x6e, 0xaf, 0xe4, 0x81, 0x5e, 0x1e, 0xaa, 0xe5, 0xdb, 0xf0,
+	0xf2, 0xa5, 0x26, 0xd3, 0xc5, 0x3f, 0x1f, 0xf9, 0x19, 0x2c, 0x0c, 0x92, 0xe9, 0x54, 0x52, 0xa2,
+	0x98, 0x04, 0x2c, 0x9c, 0xc7, 0xbf, 0x80, 0x9f, 0x83, 0xb7, 0x26, 0x6a, 0x56, 0xd6, 0xa9, 0xc4,
+	0x34, 0x60, 0xe1, 0x34, 0x86, 0x1d, 0xea, 0xe5, 0xfc, 0x02, 0xfc, 0xcc, 0x2d, 0xb0, 0xab, 0x4f,
+	0xab, 0x6b, 0x31, 0x0b, 0x58, 0xe8, 0xc7, 0xde, 0xc0, 0x5e, 0xad, 0xae, 0x4f, 0x97, 0xf0, 0xff,
+	0xcf, 0x72, 0x7e, 0x04, 0xe3, 0x0d, 0x76, 0x43, 0xc8, 0x5d, 0xcb, 0x4f, 0x60, 0xba, 0x55, 0x65,
+	0xbb, 0x4f, 0xd8, 0x0f, 0x0f, 0xa3, 0x7b, 0xf6, 0x78, 0xf3, 0x71, 0x9d, 0x17, 0xb4, 0x6e, 0x13,
+	0x99, 0xea, 0x2a, 0x52, 0x79, 0x41, 0x19, 0x6e, 0x53, 0xac, 0x09, 0x4d, 0xe4, 0xb2, 0xf6, 0xd7,
+	0x5d, 0xba, 0x9a, 0xcc, 0xdc, 0x91, 0xef, 0xbe, 0x07, 0x00, 0x6c, 0xa5, 0x68, 0x95, 0x79, 0x01,
+	0x00, 0x00,
+}
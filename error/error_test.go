@@ -0,0 +1,92 @@
+package error
+
+import (
+	standardJSON "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	pkgErrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWrap(t *testing.T) {
+	errNotFound := Define(Definition{Code: "T404", Message: "not found", HTTPStatus: http.StatusNotFound})
+
+	err := fmt.Errorf("find account: %w", errNotFound.Wrap(io.EOF))
+	assert.True(t, errors.Is(err, errNotFound))
+	assert.True(t, errors.Is(err, io.EOF))
+
+	applicationError, ok := As(err)
+	assert.True(t, ok)
+	assert.Equal(t, "T404", applicationError.ErrorCode)
+
+	assert.Equal(t, io.EOF, pkgErrors.Cause(Wrap(io.EOF, "99", "general error")))
+	assert.Equal(t, "T404", func() string {
+		applicationError, _ := As(pkgErrors.Wrap(errNotFound, "lookup"))
+		return applicationError.ErrorCode
+	}())
+
+	assert.Nil(t, Wrap(nil, "99", "general error"))
+	assert.False(t, errors.Is(New("T500", "other"), errNotFound))
+}
+
+func TestRegistry(t *testing.T) {
+	Register(Definition{Code: "T429", HTTPStatus: http.StatusTooManyRequests, Retryable: true})
+	Register(Definition{Code: "T503", GRPCCode: codes.Unavailable})
+
+	assert.Equal(t, http.StatusTooManyRequests, HTTPStatus(New("T429", "slow down")))
+	assert.Equal(t, codes.ResourceExhausted, GRPCCode(New("T429", "slow down")))
+	assert.True(t, IsRetryable(New("T429", "slow down")))
+
+	assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(New("T503", "maintenance")))
+	assert.Equal(t, codes.Unavailable, status.Code(New("T503", "maintenance")))
+
+	// the error itself wins over its definition
+	err := &ApplicationError{ErrorCode: "T429", Message: "conflict", HTTPStatus: http.StatusConflict}
+	assert.Equal(t, http.StatusConflict, HTTPStatus(err))
+
+	assert.Equal(t, 0, HTTPStatus(New("unregistered", "unknown")))
+	assert.Equal(t, codes.Unknown, GRPCCode(New("unregistered", "unknown")))
+	assert.False(t, IsRetryable(New("unregistered", "unknown")))
+
+	withMetadata := Define(Definition{Code: "T400"}).WithMetadata("field", "amount")
+	assert.Equal(t, map[string]string{"field": "amount"}, withMetadata.Metadata)
+}
+
+func TestStatus(t *testing.T) {
+	errBalance := Define(Definition{Code: "T51", Message: "insufficient balance", HTTPStatus: http.StatusUnprocessableEntity})
+	sent := errBalance.WithMetadata("account", "0812").Wrap(io.EOF)
+
+	// through the wire format of the status
+	data, err := proto.Marshal(status.Convert(sent).Proto())
+	assert.NoError(t, err)
+	received := &spb.Status{}
+	assert.NoError(t, proto.Unmarshal(data, received))
+
+	err = FromStatus(status.FromProto(received).Err())
+	applicationError, ok := As(err)
+	assert.True(t, ok)
+	assert.Equal(t, "T51", applicationError.ErrorCode)
+	assert.Equal(t, "insufficient balance", applicationError.Message)
+	assert.Equal(t, map[string]string{"account": "0812"}, applicationError.Metadata)
+	assert.Equal(t, http.StatusUnprocessableEntity, applicationError.HTTPStatus)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.True(t, errors.Is(err, errBalance))
+
+	details := NewWithDetails("T97", "invalid request", []map[string]string{{"field": "amount"}})
+	err = FromStatus(status.Convert(details).Err())
+	applicationError, _ = As(err)
+	encoded, _ := standardJSON.Marshal(applicationError.Details)
+	assert.JSONEq(t, `[{"field":"amount"}]`, string(encoded))
+
+	plain := status.Error(codes.NotFound, "not found")
+	assert.Equal(t, plain, FromStatus(plain))
+	assert.Nil(t, FromStatus(nil))
+}
@@ -0,0 +1,111 @@
+package error
+
+import (
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Definition HTTP status, gRPC code and retry policy shared by every error of a code
+type Definition struct {
+	Code       string     `json:"code"`
+	Message    string     `json:"message"`
+	HTTPStatus int        `json:"httpStatus"`
+	GRPCCode   codes.Code `json:"grpcCode"`
+	Retryable  bool       `json:"retryable"`
+}
+
+var registry = struct {
+	sync.RWMutex
+	definitions map[string]Definition
+}{definitions: make(map[string]Definition)}
+
+// Register adds or replaces definitions
+func Register(definitions ...Definition) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for _, definition := range definitions {
+		registry.definitions[definition.Code] = definition
+	}
+}
+
+func Lookup(code string) (definition Definition, ok bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	definition, ok = registry.definitions[code]
+	return
+}
+
+// Define registers definition and returns its error, compare with errors.Is and wrap causes with its Wrap method
+func Define(definition Definition) *ApplicationError {
+	Register(definition)
+
+	return &ApplicationError{
+		ErrorCode:  definition.Code,
+		Message:    definition.Message,
+		HTTPStatus: definition.HTTPStatus,
+		GRPCCode:   definition.GRPCCode,
+		Retryable:  definition.Retryable,
+	}
+}
+
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+func codeFromHTTPStatus(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusOK:
+		return codes.OK
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	}
+
+	if httpStatus >= http.StatusInternalServerError {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
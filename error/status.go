@@ -0,0 +1,67 @@
+package error
+
+import (
+	standardJSON "encoding/json"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//go:generate protoc --go_out=paths=source_relative:.. --proto_path=.. error/error.proto
+
+// Status gRPC status of the error with the error itself in the details
+func (e *ApplicationError) Status() *status.Status {
+	code := GRPCCode(e)
+	if code == codes.OK {
+		code = codes.Unknown
+	}
+
+	detail := &StatusDetail{
+		Code:       e.ErrorCode,
+		Message:    e.Message,
+		Metadata:   e.Metadata,
+		Retryable:  e.Retryable,
+		HttpStatus: int32(HTTPStatus(e)),
+	}
+	if e.Details != nil {
+		detail.DetailsJson, _ = standardJSON.Marshal(e.Details)
+	}
+
+	st := status.New(code, e.Message)
+	if withDetails, err := st.WithDetails(detail); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+// FromStatus application error decoded from the details of a gRPC status error, the status error is its cause.
+// Errors without application error details are returned as is.
+func FromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || st == nil {
+		return err
+	}
+
+	for _, detail := range st.Details() {
+		statusDetail, ok := detail.(*StatusDetail)
+		if !ok {
+			continue
+		}
+
+		applicationError := &ApplicationError{
+			ErrorCode:  statusDetail.Code,
+			Message:    statusDetail.Message,
+			HTTPStatus: int(statusDetail.HttpStatus),
+			GRPCCode:   st.Code(),
+			Retryable:  statusDetail.Retryable,
+			Metadata:   statusDetail.Metadata,
+			cause:      err,
+		}
+		if len(statusDetail.DetailsJson) > 0 {
+			applicationError.Details = standardJSON.RawMessage(statusDetail.DetailsJson)
+		}
+		return applicationError
+	}
+
+	return err
+}
@@ -13,5 +13,4 @@ const (
 
 const (
 	XRequestID = "RequestID"
-	sessionKey = "session_key"
 )
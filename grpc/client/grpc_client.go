@@ -7,6 +7,7 @@ import (
 	"time"
 
 	ConsulGRPC "github.com/agitdevcenter/gopkg/consul/grpc"
+	Error "github.com/agitdevcenter/gopkg/error"
 	Session "github.com/agitdevcenter/gopkg/session"
 )
 
@@ -17,7 +18,7 @@ type RpcConnection struct {
 
 func (rpc *RpcConnection) CreateContext(parent context.Context, session *Session.Session) (ctx context.Context) {
 	ctx, _ = context.WithTimeout(parent, rpc.options.Timeout*time.Second)
-	ctx = context.WithValue(ctx, sessionKey, session)
+	ctx = Session.NewContext(ctx, session)
 	return
 }
 
@@ -79,11 +80,17 @@ func clientInterceptor(
 	invoker grpc.UnaryInvoker,
 	opts ...grpc.CallOption,
 ) error {
-	session := ctx.Value(sessionKey).(*Session.Session)
-	ctxWithMetadata := metadata.NewOutgoingContext(ctx, metadata.Pairs(XRequestID, session.ThreadID))
+	session, ok := Session.FromContext(ctx)
+	if !ok {
+		// application errors of the server are decoded back from the status details
+		return Error.FromStatus(invoker(ctx, method, req, reply, cc, opts...))
+	}
+
+	ctxWithMetadata := metadata.AppendToOutgoingContext(ctx, XRequestID, session.ThreadID)
 	processTime := session.T2("[request]", method, req)
 	err := invoker(ctxWithMetadata, method, req, reply, cc, opts...)
 	if err != nil {
+		err = Error.FromStatus(err)
 		session.T3(processTime, "[response][error]", method, err)
 		return err
 	}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	Error "github.com/agitdevcenter/gopkg/error"
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Session "github.com/agitdevcenter/gopkg/session"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func applicationStatus() error {
+	return Error.New("E001", "not found").(*Error.ApplicationError).GRPCStatus().Err()
+}
+
+func TestClientInterceptorWithoutSession(t *testing.T) {
+	ctx := context.Background()
+
+	var invoked context.Context
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		invoked = ctx
+		return nil
+	}
+	assert.NoError(t, clientInterceptor(ctx, "/svc/Method", nil, nil, nil, invoker))
+	assert.Equal(t, ctx, invoked)
+	_, ok := metadata.FromOutgoingContext(invoked)
+	assert.False(t, ok)
+
+	// application errors are still decoded without a session
+	invoker = func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return applicationStatus()
+	}
+	err := clientInterceptor(ctx, "/svc/Method", nil, nil, nil, invoker)
+	var applicationError *Error.ApplicationError
+	if assert.True(t, errors.As(err, &applicationError)) {
+		assert.Equal(t, "E001", applicationError.ErrorCode)
+	}
+}
+
+func TestClientInterceptorWithSession(t *testing.T) {
+	session := Session.New(Logger.Noop()).SetThreadID("thread-1")
+	ctx := Session.NewContext(context.Background(), session)
+
+	var requestID []string
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		requestID = md.Get(XRequestID)
+		return applicationStatus()
+	}
+	err := clientInterceptor(ctx, "/svc/Method", nil, nil, nil, invoker)
+	assert.Equal(t, []string{"thread-1"}, requestID)
+
+	var applicationError *Error.ApplicationError
+	if assert.True(t, errors.As(err, &applicationError)) {
+		assert.Equal(t, "E001", applicationError.ErrorCode)
+	}
+}
@@ -2,26 +2,130 @@ package health
 
 import (
 	"context"
+	"time"
+
+	"github.com/agitdevcenter/gopkg/health"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
-func RegisterHealthServer(s *grpc.Server) {
-	grpc_health_v1.RegisterHealthServer(s, newHandler())
+const DefaultWatchInterval = 10 * time.Second
+
+type Option func(*healthImpl)
+
+// WithRegistry checks answered instead of health.DefaultRegistry
+func WithRegistry(registry *health.Registry) Option {
+	return func(h *healthImpl) {
+		if registry != nil {
+			h.registry = registry
+		}
+	}
 }
 
-type healthImpl struct{}
+// WithWatchInterval interval between checks of a Watch stream, default is DefaultWatchInterval
+func WithWatchInterval(interval time.Duration) Option {
+	return func(h *healthImpl) {
+		if interval > 0 {
+			h.interval = interval
+		}
+	}
+}
+
+// RegisterHealthServer serves the checks of the health registry, a service is NOT_SERVING
+// when a critical check it depends on is failing, see health.Check.Services
+func RegisterHealthServer(s *grpc.Server, opts ...Option) {
+	grpc_health_v1.RegisterHealthServer(s, newHandler(s, opts))
+}
 
-func newHandler() grpc_health_v1.HealthServer {
-	return &healthImpl{}
+type healthImpl struct {
+	server   *grpc.Server
+	registry *health.Registry
+	interval time.Duration
+}
+
+func newHandler(s *grpc.Server, opts []Option) grpc_health_v1.HealthServer {
+	h := &healthImpl{
+		server:   s,
+		registry: health.DefaultRegistry,
+		interval: DefaultWatchInterval,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 func (h *healthImpl) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	servingStatus := h.status(ctx, req.GetService())
+	if servingStatus == grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN {
+		return nil, status.Errorf(codes.NotFound, "unknown service %s", req.GetService())
+	}
+
 	return &grpc_health_v1.HealthCheckResponse{
-		Status: grpc_health_v1.HealthCheckResponse_SERVING,
+		Status: servingStatus,
 	}, nil
 }
 
+// Watch sends the status of the service when it changes, checks run every interval and on registry changes
 func (h *healthImpl) Watch(req *grpc_health_v1.HealthCheckRequest, w grpc_health_v1.Health_WatchServer) error {
-	return nil
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	sent := false
+	var last grpc_health_v1.HealthCheckResponse_ServingStatus
+	for {
+		changed := h.registry.Changed()
+
+		servingStatus := h.status(w.Context(), req.GetService())
+		if !sent || servingStatus != last {
+			if err := w.Send(&grpc_health_v1.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return err
+			}
+			sent = true
+			last = servingStatus
+		}
+
+		select {
+		case <-w.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-ticker.C:
+		case <-changed:
+		}
+	}
+}
+
+func (h *healthImpl) status(ctx context.Context, service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if !h.known(service) {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+
+	if h.registry.CheckService(ctx, service).Status == health.StatusDown {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING
+}
+
+// known the whole server, services registered on the server and services named by checks
+func (h *healthImpl) known(service string) bool {
+	if len(service) == 0 {
+		return true
+	}
+
+	if h.server != nil {
+		if _, ok := h.server.GetServiceInfo()[service]; ok {
+			return true
+		}
+	}
+
+	for _, named := range h.registry.Services() {
+		if named == service {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,283 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusUp Status = "UP"
+	// StatusDegraded only non critical checks are failing
+	StatusDegraded Status = "DEGRADED"
+	// StatusDown at least one critical check is failing
+	StatusDown Status = "DOWN"
+
+	DefaultTimeout = 5 * time.Second
+
+	// NotReadyCheck name of the failing result reported while the registry is not ready
+	NotReadyCheck = "ready"
+)
+
+// Checker returns an error when the component is not healthy, ex: database clients ping
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function into a Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Check checker registered with its name
+type Check struct {
+	Name    string
+	Checker Checker
+	// Timeout of one run of the checker, default is DefaultTimeout
+	Timeout time.Duration
+	// Critical failing checks are DOWN, the others are DEGRADED
+	Critical bool
+	// Services gRPC services depending on the check, the whole server depends on every check
+	Services []string
+}
+
+// Result of one check, duration is in ms
+type Result struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Critical bool   `json:"critical"`
+	Duration int64  `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report aggregated status of the checks
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Registry checks of the components, safe for concurrent use
+type Registry struct {
+	mutex   sync.RWMutex
+	checks  []Check
+	ready   bool
+	changed chan struct{}
+}
+
+// DefaultRegistry used by the package functions, the HTTP middleware and the gRPC health service
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{ready: true, changed: make(chan struct{})}
+}
+
+// SetReady false makes every check DOWN without running the checkers, ex: while the transport starts or drains
+func (r *Registry) SetReady(ready bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.ready != ready {
+		r.ready = ready
+		r.notify()
+	}
+}
+
+func (r *Registry) Ready() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.ready
+}
+
+// Register adds the check or replaces the check with the same name
+func (r *Registry) Register(check Check) error {
+	if len(check.Name) == 0 {
+		return errors.New("health check name is required")
+	}
+	if check.Checker == nil {
+		return fmt.Errorf("health check %s has no checker", check.Name)
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultTimeout
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, registered := range r.checks {
+		if registered.Name == check.Name {
+			r.checks[i] = check
+			r.notify()
+			return nil
+		}
+	}
+
+	r.checks = append(r.checks, check)
+	r.notify()
+	return nil
+}
+
+func (r *Registry) Unregister(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, registered := range r.checks {
+		if registered.Name == name {
+			r.checks = append(r.checks[:i:i], r.checks[i+1:]...)
+			r.notify()
+			return
+		}
+	}
+}
+
+// Changed closed on the next registration or readiness change
+func (r *Registry) Changed() <-chan struct{} {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.changed
+}
+
+// notify must be called with the lock held
+func (r *Registry) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Services gRPC services named by the checks
+func (r *Registry) Services() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var services []string
+	seen := make(map[string]bool)
+	for _, check := range r.checks {
+		for _, service := range check.Services {
+			if !seen[service] {
+				seen[service] = true
+				services = append(services, service)
+			}
+		}
+	}
+	return services
+}
+
+// Check runs every check concurrently, DOWN while the registry is not ready
+func (r *Registry) Check(ctx context.Context) Report {
+	return r.CheckService(ctx, "")
+}
+
+// Health runs every check concurrently whatever the readiness, the state of the components while
+// the transport starts or drains
+func (r *Registry) Health(ctx context.Context) Report {
+	return runChecks(ctx, r.selected(""))
+}
+
+// CheckService runs the checks the gRPC service depends on, every check for the empty service
+func (r *Registry) CheckService(ctx context.Context, service string) Report {
+	if !r.Ready() {
+		return Report{Status: StatusDown, Checks: []Result{{Name: NotReadyCheck, Status: StatusDown, Critical: true, Error: "not ready"}}}
+	}
+	return runChecks(ctx, r.selected(service))
+}
+
+// selected checks the gRPC service depends on, every check for the empty service
+func (r *Registry) selected(service string) (checks []Check) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, check := range r.checks {
+		if len(service) == 0 || contains(check.Services, service) {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+func runChecks(ctx context.Context, checks []Check) Report {
+	report := Report{Status: StatusUp, Checks: make([]Result, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Checks[i] = run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		switch {
+		case result.Status == StatusDown:
+			report.Status = StatusDown
+		case result.Status == StatusDegraded && report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// run the checker within its timeout, checkers ignoring the context are abandoned at the timeout
+func run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic : %+v", r)
+			}
+		}()
+		done <- check.Checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timeout after %s", check.Timeout)
+	}
+
+	result := Result{
+		Name:     check.Name,
+		Status:   StatusUp,
+		Critical: check.Critical,
+		Duration: time.Since(start).Nanoseconds() / 1000000,
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Status = StatusDegraded
+		if check.Critical {
+			result.Status = StatusDown
+		}
+	}
+
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Register the check in DefaultRegistry
+func Register(check Check) error {
+	return DefaultRegistry.Register(check)
+}
+
+// Unregister the check from DefaultRegistry
+func Unregister(name string) {
+	DefaultRegistry.Unregister(name)
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	up := CheckerFunc(func(ctx context.Context) error { return nil })
+	down := CheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") })
+
+	assert.Error(t, r.Register(Check{Checker: up}))
+	assert.Error(t, r.Register(Check{Name: "nil"}))
+
+	assert.NoError(t, r.Register(Check{Name: "mysql", Checker: up, Critical: true, Services: []string{"payment.Payment"}}))
+	assert.Equal(t, StatusUp, r.Check(context.Background()).Status)
+
+	changed := r.Changed()
+	assert.NoError(t, r.Register(Check{Name: "redis", Checker: down, Services: []string{"account.Account"}}))
+	<-changed
+
+	report := r.Check(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, "mysql", report.Checks[0].Name)
+
+	assert.NoError(t, r.Register(Check{Name: "mysql", Checker: down, Critical: true, Services: []string{"payment.Payment"}}))
+	assert.Equal(t, StatusDown, r.Check(context.Background()).Status)
+
+	report = r.CheckService(context.Background(), "account.Account")
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Len(t, report.Checks, 1)
+	assert.Equal(t, "connection refused", report.Checks[0].Error)
+
+	assert.ElementsMatch(t, []string{"payment.Payment", "account.Account"}, r.Services())
+
+	r.Unregister("mysql")
+	r.Unregister("redis")
+	assert.Equal(t, Report{Status: StatusUp, Checks: []Result{}}, r.Check(context.Background()))
+}
+
+func TestTimeout(t *testing.T) {
+	r := NewRegistry()
+	blocked := make(chan struct{})
+	defer close(blocked)
+
+	// the checker ignores its context
+	assert.NoError(t, r.Register(Check{Name: "kafka", Critical: true, Timeout: 20 * time.Millisecond, Checker: CheckerFunc(func(ctx context.Context) error {
+		<-blocked
+		return nil
+	})}))
+
+	report := r.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, "timeout after 20ms", report.Checks[0].Error)
+}
+
+func TestReady(t *testing.T) {
+	r := NewRegistry()
+	assert.True(t, r.Ready())
+	assert.NoError(t, r.Register(Check{Name: "mysql", Checker: CheckerFunc(func(ctx context.Context) error { return nil })}))
+
+	changed := r.Changed()
+	r.SetReady(false)
+	<-changed
+
+	report := r.CheckService(context.Background(), "")
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, NotReadyCheck, report.Checks[0].Name)
+
+	r.SetReady(true)
+	assert.Equal(t, StatusUp, r.Check(context.Background()).Status)
+}
+
+func TestReadiness(t *testing.T) {
+	r := NewRegistry()
+	assert.NoError(t, r.Register(Check{Name: "mysql", Critical: true, Checker: CheckerFunc(func(ctx context.Context) error { return nil })}))
+
+	r.SetReady(false)
+	report := r.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, NotReadyCheck, report.Checks[0].Name)
+
+	// the health of the components is still reported while draining
+	report = r.Health(context.Background())
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, "mysql", report.Checks[0].Name)
+
+	r.SetReady(true)
+	assert.Equal(t, StatusUp, r.Check(context.Background()).Status)
+}
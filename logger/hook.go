@@ -0,0 +1,292 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	ThreadIDField = "_app_thread_id"
+	messageField  = "_message_"
+
+	maxSuppressedKeys = 1000
+	// error entries waiting for the hooks, entries are dropped when it is full
+	hookQueueCapacity = 100
+	// hook errors are written to stderr at most once per interval
+	hookErrorInterval = time.Minute
+)
+
+// Hook receives every Error, Panic and Fatal entry that passes deduplication and rate limiting
+type Hook interface {
+	Fire(entry Entry) error
+}
+
+// HookFunc adapts a function into a Hook
+type HookFunc func(entry Entry) error
+
+func (f HookFunc) Fire(entry Entry) error {
+	return f(entry)
+}
+
+// Entry alert payload sent to hooks
+type Entry struct {
+	Level      string                 `json:"level"`
+	Time       time.Time              `json:"time"`
+	ThreadID   string                 `json:"threadID"`
+	Caller     string                 `json:"caller"`
+	Message    string                 `json:"message"`
+	Fields     map[string]interface{} `json:"fields"`
+	Suppressed int                    `json:"suppressed"` // number of identical entries dropped since the previous alert
+}
+
+// AlertOptions deduplication and rate limiting applied before hooks are fired
+type AlertOptions struct {
+	// Identical entries (level, caller, message and fields except thread ID) are fired once per window
+	DedupWindow time.Duration `json:"dedupWindow"`
+	// At most RateLimit entries are fired per RateInterval, zero means unlimited
+	RateLimit    int           `json:"rateLimit"`
+	RateInterval time.Duration `json:"rateInterval"`
+}
+
+type hookCore struct {
+	zapcore.LevelEnabler
+	fields     []zapcore.Field
+	dispatcher *hookDispatcher
+}
+
+func newHookCore(hooks []Hook, options AlertOptions) zapcore.Core {
+	return &hookCore{
+		LevelEnabler: zapcore.ErrorLevel,
+		dispatcher:   newHookDispatcher(hooks, options),
+	}
+}
+
+func (c *hookCore) With(fields []zapcore.Field) zapcore.Core {
+	return &hookCore{
+		LevelEnabler: c.LevelEnabler,
+		fields:       append(append([]zapcore.Field{}, c.fields...), fields...),
+		dispatcher:   c.dispatcher,
+	}
+}
+
+func (c *hookCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *hookCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range append(append([]zapcore.Field{}, c.fields...), fields...) {
+		field.AddTo(encoder)
+	}
+
+	alert := Entry{
+		Level:   entry.Level.CapitalString(),
+		Time:    entry.Time,
+		Caller:  entry.Caller.TrimmedPath(),
+		Message: alertMessage(entry.Message, encoder.Fields),
+		Fields:  encoder.Fields,
+	}
+	if threadID, ok := encoder.Fields[ThreadIDField]; ok {
+		alert.ThreadID = fmt.Sprint(threadID)
+	}
+
+	// panic and fatal entries are delivered before the process goes down
+	if entry.Level > zapcore.ErrorLevel {
+		c.dispatcher.dispatch(alert)
+		return nil
+	}
+
+	c.dispatcher.enqueue(alert)
+	return nil
+}
+
+func (c *hookCore) Sync() error {
+	return nil
+}
+
+// hookDispatcher fires the hooks from a single worker fed by a bounded queue
+type hookDispatcher struct {
+	hooks      []Hook
+	options    AlertOptions
+	mutex      sync.Mutex
+	sent       map[string]time.Time
+	suppressed map[string]int
+	rateStart  time.Time
+	rateCount  int
+
+	queue     chan Entry
+	startOnce sync.Once
+	stderr    io.Writer
+	failures  int
+	reported  time.Time
+}
+
+func newHookDispatcher(hooks []Hook, options AlertOptions) *hookDispatcher {
+	if options.RateLimit > 0 && options.RateInterval <= 0 {
+		options.RateInterval = time.Minute
+	}
+
+	return &hookDispatcher{
+		hooks:      hooks,
+		options:    options,
+		sent:       make(map[string]time.Time),
+		suppressed: make(map[string]int),
+		queue:      make(chan Entry, hookQueueCapacity),
+		stderr:     os.Stderr,
+	}
+}
+
+// enqueue hands entry over to the worker, entries above the queue capacity are dropped
+func (d *hookDispatcher) enqueue(entry Entry) {
+	var ok bool
+	if entry, ok = d.allow(entry); !ok {
+		return
+	}
+
+	d.startOnce.Do(func() {
+		go func() {
+			for entry := range d.queue {
+				d.fire(entry)
+			}
+		}()
+	})
+
+	select {
+	case d.queue <- entry:
+	default:
+		d.fail(errors.New("alert queue is full, entry dropped"))
+	}
+}
+
+func (d *hookDispatcher) dispatch(entry Entry) {
+	var ok bool
+	if entry, ok = d.allow(entry); !ok {
+		return
+	}
+	d.fire(entry)
+}
+
+func (d *hookDispatcher) fire(entry Entry) {
+	for _, hook := range d.hooks {
+		// a failing hook must never take the application down
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					d.fail(fmt.Errorf("alert hook panic : %+v", r))
+				}
+			}()
+			if err := hook.Fire(entry); err != nil {
+				d.fail(err)
+			}
+		}()
+	}
+}
+
+// fail writes hook errors to stderr, the logger itself would alert again
+func (d *hookDispatcher) fail(err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.failures++
+	now := time.Now()
+	if now.Sub(d.reported) < hookErrorInterval {
+		return
+	}
+
+	fmt.Fprintf(d.stderr, "logger: alert hook error : %+v (%d errors since the last report)\n", err, d.failures)
+	d.failures = 0
+	d.reported = now
+}
+
+func (d *hookDispatcher) allow(entry Entry) (Entry, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := time.Now()
+
+	key := fingerprint(entry)
+	if d.options.DedupWindow > 0 {
+		for k, sent := range d.sent {
+			if now.Sub(sent) >= d.options.DedupWindow {
+				delete(d.sent, k)
+			}
+		}
+
+		if _, ok := d.sent[key]; ok {
+			d.suppressed[key]++
+			return entry, false
+		}
+	}
+
+	if d.options.RateLimit > 0 {
+		if now.Sub(d.rateStart) >= d.options.RateInterval {
+			d.rateStart = now
+			d.rateCount = 0
+		}
+		if d.rateCount >= d.options.RateLimit {
+			d.suppressed[key]++
+			return entry, false
+		}
+		d.rateCount++
+	}
+
+	if d.options.DedupWindow > 0 {
+		d.sent[key] = now
+	}
+
+	entry.Suppressed = d.suppressed[key]
+	delete(d.suppressed, key)
+
+	// bound memory for keys that never get another chance to be reported
+	if len(d.suppressed) > maxSuppressedKeys {
+		d.suppressed = make(map[string]int)
+	}
+
+	return entry, true
+}
+
+func fingerprint(entry Entry) string {
+	keys := make([]string, 0, len(entry.Fields))
+	for k := range entry.Fields {
+		if k != ThreadIDField {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString(entry.Level + "|" + entry.Caller + "|" + entry.Message)
+	for _, k := range keys {
+		builder.WriteString(fmt.Sprintf("|%s=%v", k, entry.Fields[k]))
+	}
+	return builder.String()
+}
+
+// alertMessage joins the log message with the session "_message_N" fields
+func alertMessage(message string, fields map[string]interface{}) string {
+	parts := []string{}
+	if trimmed := strings.TrimSpace(message); len(trimmed) > 0 && trimmed != "|" {
+		parts = append(parts, trimmed)
+	}
+
+	for i := 0; ; i++ {
+		value, ok := fields[fmt.Sprintf("%s%d", messageField, i)]
+		if !ok {
+			break
+		}
+		parts = append(parts, fmt.Sprint(value))
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"bytes"
+	standardJSON "encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestWebhookHook(t *testing.T) {
+	assert := assert.New(t)
+
+	received := make(chan webhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		standardJSON.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer server.Close()
+
+	options := newOptions("log.log", "tdr.log", time.Minute, true)
+	options.Hooks = []Hook{NewWebhook(WebhookOptions{URL: server.URL, AppName: "Testing"})}
+
+	logger := newLogger(options)
+	logger.Error("|", zap.String(ThreadIDField, "thread-1"), zap.String("_message_0", "database down"))
+
+	select {
+	case payload := <-received:
+		assert.Equal("Testing", payload.AppName)
+		assert.Equal("ERROR", payload.Level)
+		assert.Equal("thread-1", payload.ThreadID)
+		assert.Equal("database down", payload.Message)
+		assert.NotEmpty(payload.Caller)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+}
+
+func TestHookDeduplication(t *testing.T) {
+	assert := assert.New(t)
+
+	var fired []Entry
+	dispatcher := newHookDispatcher([]Hook{HookFunc(func(entry Entry) error {
+		fired = append(fired, entry)
+		return nil
+	})}, AlertOptions{DedupWindow: 50 * time.Millisecond})
+
+	entry := Entry{Level: "ERROR", Message: "boom", Fields: map[string]interface{}{ThreadIDField: "a"}}
+	dispatcher.dispatch(entry)
+
+	entry.Fields = map[string]interface{}{ThreadIDField: "b"}
+	dispatcher.dispatch(entry)
+	assert.Len(fired, 1)
+
+	time.Sleep(60 * time.Millisecond)
+	dispatcher.dispatch(entry)
+	assert.Len(fired, 2)
+	assert.Equal(1, fired[1].Suppressed)
+}
+
+func TestHookRateLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	count := 0
+	dispatcher := newHookDispatcher([]Hook{HookFunc(func(entry Entry) error {
+		count++
+		return nil
+	})}, AlertOptions{RateLimit: 2, RateInterval: time.Hour})
+
+	for _, message := range []string{"a", "b", "c", "d"} {
+		dispatcher.dispatch(Entry{Level: "ERROR", Message: message})
+	}
+
+	assert.Equal(2, count)
+}
+
+func TestHookQueue(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+	dispatcher := newHookDispatcher([]Hook{HookFunc(func(entry Entry) error {
+		<-release
+		return nil
+	})}, AlertOptions{})
+	var stderr bytes.Buffer
+	dispatcher.stderr = &stderr
+
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 10*hookQueueCapacity; i++ {
+		dispatcher.enqueue(Entry{Level: "ERROR", Message: "boom"})
+	}
+
+	// a single worker delivers the entries, the ones above the queue capacity are dropped
+	assert.True(runtime.NumGoroutine()-goroutines <= 1)
+	close(release)
+
+	dispatcher.mutex.Lock()
+	defer dispatcher.mutex.Unlock()
+	assert.Contains(stderr.String(), "alert queue is full")
+	assert.Equal(1, strings.Count(stderr.String(), "\n"))
+}
+
+func TestHookError(t *testing.T) {
+	assert := assert.New(t)
+
+	dispatcher := newHookDispatcher([]Hook{
+		HookFunc(func(entry Entry) error {
+			return errors.New("webhook unreachable")
+		}),
+		HookFunc(func(entry Entry) error {
+			panic("nil map")
+		}),
+	}, AlertOptions{})
+	var stderr bytes.Buffer
+	dispatcher.stderr = &stderr
+
+	dispatcher.dispatch(Entry{Level: "ERROR", Message: "a"})
+	dispatcher.dispatch(Entry{Level: "ERROR", Message: "b"})
+
+	// reported once per interval
+	assert.Equal("logger: alert hook error : webhook unreachable (1 errors since the last report)\n", stderr.String())
+	assert.Equal(3, dispatcher.failures)
+}
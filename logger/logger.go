@@ -2,10 +2,12 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path"
 	"runtime"
+	"sync"
 	"time"
 
 	grpc_logging "github.com/grpc-ecosystem/go-grpc-middleware/logging"
@@ -32,7 +34,20 @@ type Logger interface {
 	TDR(tdr LogTdrModel)
 }
 
+// Leveler logger changing its level while running, ex: the logger of New
+type Leveler interface {
+	Level() string
+	SetLevel(level string) error
+}
+
 func New(config Options) Logger {
+	level := zap.NewAtomicLevel()
+	if len(config.Level) > 0 {
+		if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+			panic(err)
+		}
+	}
+
 	cores := []zapcore.Core{}
 
 	var writer zapcore.WriteSyncer
@@ -52,9 +67,13 @@ func New(config Options) Logger {
 		writer = zapcore.AddSync(rotate)
 	}
 
-	core := zapcore.NewCore(getEncoder(), writer, zapcore.InfoLevel)
+	core := zapcore.NewCore(getEncoder(), writer, level)
 	cores = append(cores, core)
 
+	if len(config.Hooks) > 0 {
+		cores = append(cores, newHookCore(config.Hooks, config.Alert))
+	}
+
 	combinedCore := zapcore.NewTee(cores...)
 
 	logger := zap.New(combinedCore,
@@ -88,12 +107,53 @@ func New(config Options) Logger {
 	return &zapLogger{
 		logger:    logger,
 		loggerTdr: loggerTdr,
+		level:     level,
+		tdr:       newTdrFilter(config.Tdr),
+		reload:    config.Reload,
 	}
 }
 
 type zapLogger struct {
 	logger    *zap.Logger
 	loggerTdr *zap.Logger
+	level     zap.AtomicLevel
+	mutex     sync.RWMutex
+	tdr       *tdrFilter
+	reload    func() (Options, error)
+}
+
+// Level minimum level of the application log
+func (l *zapLogger) Level() string {
+	return l.level.String()
+}
+
+// SetLevel changes the minimum level of the application log while running, TDR are always written
+func (l *zapLogger) SetLevel(level string) error {
+	return l.level.UnmarshalText([]byte(level))
+}
+
+// Reload applies Level and Tdr of the options returned by Options.Reload
+func (l *zapLogger) Reload(ctx context.Context) error {
+	if l.reload == nil {
+		return nil
+	}
+
+	config, err := l.reload()
+	if err != nil {
+		return fmt.Errorf("reloading logger options : %+v", err)
+	}
+
+	if len(config.Level) > 0 {
+		if err := l.SetLevel(config.Level); err != nil {
+			return fmt.Errorf("reloading logger level : %+v", err)
+		}
+	}
+
+	l.mutex.Lock()
+	l.tdr = newTdrFilter(config.Tdr)
+	l.mutex.Unlock()
+
+	return nil
 }
 
 type LogTdrModel struct {
@@ -111,6 +171,17 @@ type LogTdrModel struct {
 	ThreadID       string      `json:"threadID"`
 	ResponseCode   string      `json:"rc"`
 	AdditionalData interface{} `json:"addData"`
+	Timeline       []TdrStep   `json:"timeline"`
+}
+
+// TdrStep timeline entry, start is the offset from the request time and both start and duration are in ms
+type TdrStep struct {
+	Name     string    `json:"name"`
+	Start    int64     `json:"start"`
+	Duration int64     `json:"duration"`
+	Error    string    `json:"error,omitempty"`
+	Ended    bool      `json:"ended"`
+	Steps    []TdrStep `json:"steps,omitempty"`
 }
 
 func getEncoder() zapcore.Encoder {
@@ -165,6 +236,15 @@ func (l *zapLogger) Panic(message string, fields ...zap.Field) {
 }
 
 func (l *zapLogger) TDR(model LogTdrModel) {
+	l.mutex.RLock()
+	tdr := l.tdr
+	l.mutex.RUnlock()
+
+	var ok bool
+	if model, ok = tdr.apply(model); !ok {
+		return
+	}
+
 	fields := []zap.Field{zap.String("xid", model.ThreadID),
 		zap.Int64("rt", model.RespTime),
 		zap.Int("port", model.Port),
@@ -181,6 +261,9 @@ func (l *zapLogger) TDR(model LogTdrModel) {
 	if model.ResponseCode != "" {
 		fields = append(fields, zap.String("rc", model.ResponseCode))
 	}
+	if len(model.Timeline) > 0 {
+		fields = append(fields, zap.Any("timeline", model.Timeline))
+	}
 	l.loggerTdr.Info("|", fields...)
 }
 
@@ -1,10 +1,12 @@
 package logger
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
 )
 
 type Coordinate struct {
@@ -193,3 +195,23 @@ func BenchmarkTDRLogger(b *testing.B) {
 		logger.TDR(newTDR(appName, appVersion, ip, srcIP, path, port, respTime, headers, request, response))
 	}
 }
+
+func TestReload(t *testing.T) {
+	options := newOptions("", "", time.Hour, true)
+	options.Level = "warn"
+	options.Reload = func() (Options, error) {
+		return Options{Level: "debug", Tdr: TdrOptions{MaxRequestSize: 10}}, nil
+	}
+
+	logger := New(options).(*zapLogger)
+	assert.Equal(t, "warn", logger.Level())
+	assert.False(t, logger.logger.Core().Enabled(zapcore.InfoLevel))
+
+	assert.NoError(t, logger.Reload(context.Background()))
+	assert.Equal(t, "debug", logger.Level())
+	assert.True(t, logger.logger.Core().Enabled(zapcore.DebugLevel))
+	assert.Equal(t, 10, logger.tdr.options.MaxRequestSize)
+
+	assert.Error(t, logger.SetLevel("verbose"))
+	assert.Equal(t, "debug", logger.Level())
+}
@@ -3,8 +3,15 @@ package logger
 import "time"
 
 type Options struct {
+	// Level minimum level of the application log, debug, info, warn or error, default is info
+	Level           string        `json:"level"`
 	FileLocation    string        `json:"fileLocation"`
 	FileTdrLocation string        `json:"fileTdrLocation"`
 	FileMaxAge      time.Duration `json:"fileMaxAge"`
 	Stdout          bool          `json:"stdout"`
+	Tdr             TdrOptions    `json:"tdr"`
+	Hooks           []Hook        `json:"-"`
+	Alert           AlertOptions  `json:"alert"`
+	// Reload returns the options applied by Reload, only Level and Tdr are applied while running
+	Reload func() (Options, error) `json:"-"`
 }
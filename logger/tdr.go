@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"bytes"
+	standardJSON "encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	TruncatedMarker   = "...[truncated %d bytes]"
+	SkippedBodyMarker = "[body skipped]"
+)
+
+// TdrOptions limits and sampling applied to every TDR record before it is written
+type TdrOptions struct {
+	// Maximum size in bytes of the serialized request, response and header fields, zero means unlimited
+	MaxRequestSize  int `json:"maxRequestSize"`
+	MaxResponseSize int `json:"maxResponseSize"`
+	MaxHeaderSize   int `json:"maxHeaderSize"`
+
+	// Request and response bodies are replaced by SkippedBodyMarker when the request content type
+	// or the path starts with one of these prefixes, ex: multipart/, application/octet-stream, /v1/report
+	SkipBodyContentTypes []string `json:"skipBodyContentTypes"`
+	SkipBodyPaths        []string `json:"skipBodyPaths"`
+
+	Sampling TdrSampling `json:"sampling"`
+}
+
+// TdrSampling sampling of successful TDR records, records carrying an error are always logged
+type TdrSampling struct {
+	Enabled bool `json:"enabled"`
+	// Probability between 0 and 1 to log a successful record
+	Rate float64 `json:"rate"`
+	// Per route probability keyed by path prefix, the longest matching prefix wins over Rate
+	Routes map[string]float64 `json:"routes"`
+	// Response codes considered successful, default is "00"
+	SuccessCodes []string `json:"successCodes"`
+}
+
+type tdrFilter struct {
+	options TdrOptions
+}
+
+func newTdrFilter(options TdrOptions) *tdrFilter {
+	if len(options.Sampling.SuccessCodes) == 0 {
+		options.Sampling.SuccessCodes = []string{"00"}
+	}
+
+	contentTypes := make([]string, len(options.SkipBodyContentTypes))
+	for i, contentType := range options.SkipBodyContentTypes {
+		contentTypes[i] = strings.ToLower(contentType)
+	}
+	options.SkipBodyContentTypes = contentTypes
+
+	return &tdrFilter{options: options}
+}
+
+// apply returns the model to be written and false when the model is dropped by sampling
+func (f *tdrFilter) apply(model LogTdrModel) (LogTdrModel, bool) {
+	if !f.sample(model) {
+		return model, false
+	}
+
+	if f.skipBody(model) {
+		model.Request = SkippedBodyMarker
+		model.Response = SkippedBodyMarker
+	}
+
+	model.Request = truncate(model.Request, f.options.MaxRequestSize)
+	model.Response = truncate(model.Response, f.options.MaxResponseSize)
+	model.Header = truncate(model.Header, f.options.MaxHeaderSize)
+
+	return model, true
+}
+
+func (f *tdrFilter) sample(model LogTdrModel) bool {
+	sampling := f.options.Sampling
+	if !sampling.Enabled || !f.success(model) {
+		return true
+	}
+
+	rate := sampling.Rate
+	matched := -1
+	for prefix, routeRate := range sampling.Routes {
+		if strings.HasPrefix(model.Path, prefix) && len(prefix) > matched {
+			matched = len(prefix)
+			rate = routeRate
+		}
+	}
+
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+
+	return rand.Float64() < rate
+}
+
+func (f *tdrFilter) success(model LogTdrModel) bool {
+	if len(model.Error) > 0 {
+		return false
+	}
+
+	if len(model.ResponseCode) == 0 {
+		return true
+	}
+
+	for _, code := range f.options.Sampling.SuccessCodes {
+		if model.ResponseCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *tdrFilter) skipBody(model LogTdrModel) bool {
+	for _, path := range f.options.SkipBodyPaths {
+		if strings.HasPrefix(model.Path, path) {
+			return true
+		}
+	}
+
+	if len(f.options.SkipBodyContentTypes) > 0 {
+		contentType := strings.ToLower(headerValue(model.Header, "Content-Type"))
+		for _, skip := range f.options.SkipBodyContentTypes {
+			if len(contentType) > 0 && strings.HasPrefix(contentType, skip) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func truncate(obj interface{}, max int) interface{} {
+	if max <= 0 || obj == nil {
+		return obj
+	}
+
+	var data []byte
+	switch value := obj.(type) {
+	case string:
+		if len(value) <= max {
+			return value
+		}
+		data = []byte(value)
+	case []byte:
+		data = value
+	case proto.Message:
+		b := &bytes.Buffer{}
+		if err := JsonPbMarshaller.Marshal(b, value); err != nil {
+			return obj
+		}
+		data = b.Bytes()
+	default:
+		// measured with encoding/json, the same encoder zap uses for reflected fields
+		var err error
+		if data, err = standardJSON.Marshal(value); err != nil {
+			return obj
+		}
+	}
+
+	if len(data) <= max {
+		return obj
+	}
+
+	// backs off to a rune boundary so the truncated value stays valid UTF-8
+	cut := max
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+
+	return string(data[:cut]) + fmt.Sprintf(TruncatedMarker, len(data)-cut)
+}
+
+func headerValue(header interface{}, key string) string {
+	switch h := header.(type) {
+	case http.Header:
+		return h.Get(key)
+	case map[string][]string:
+		return http.Header(h).Get(key)
+	case map[string]string:
+		for k, v := range h {
+			if strings.EqualFold(k, key) {
+				return v
+			}
+		}
+	case map[string]interface{}:
+		for k, v := range h {
+			if !strings.EqualFold(k, key) {
+				continue
+			}
+			switch value := v.(type) {
+			case string:
+				return value
+			case []string:
+				if len(value) > 0 {
+					return value[0]
+				}
+			}
+		}
+	}
+	return ""
+}
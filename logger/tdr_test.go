@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTdrTruncate(t *testing.T) {
+	assert := assert.New(t)
+
+	filter := newTdrFilter(TdrOptions{MaxRequestSize: 10, MaxResponseSize: 10, MaxHeaderSize: 10})
+
+	model, ok := filter.apply(LogTdrModel{
+		Request:  strings.Repeat("a", 25),
+		Response: Response{Message: strings.Repeat("b", 25)},
+		Header:   map[string]string{"a": "b"},
+	})
+
+	assert.True(ok)
+	assert.Equal(strings.Repeat("a", 10)+fmt.Sprintf(TruncatedMarker, 15), model.Request)
+	assert.True(strings.HasPrefix(model.Response.(string), `{"Success"`))
+	assert.True(strings.HasSuffix(model.Response.(string), "bytes]"))
+	assert.Equal(map[string]string{"a": "b"}, model.Header)
+
+	// "é" is 2 bytes, the 10th byte is in the middle of the 5th rune
+	model, _ = filter.apply(LogTdrModel{Request: "a" + strings.Repeat("é", 10)})
+	assert.Equal("a"+strings.Repeat("é", 4)+fmt.Sprintf(TruncatedMarker, 12), model.Request)
+	assert.True(utf8.ValidString(model.Request.(string)))
+}
+
+func TestTdrSkipBody(t *testing.T) {
+	assert := assert.New(t)
+
+	filter := newTdrFilter(TdrOptions{
+		SkipBodyContentTypes: []string{"Multipart/"},
+		SkipBodyPaths:        []string{"/v1/report"},
+	})
+
+	header := http.Header{}
+	header.Set("Content-Type", "multipart/form-data; boundary=x")
+
+	model, _ := filter.apply(LogTdrModel{Path: "/v1/upload", Header: header, Request: "file", Response: "ok"})
+	assert.Equal(SkippedBodyMarker, model.Request)
+	assert.Equal(SkippedBodyMarker, model.Response)
+
+	model, _ = filter.apply(LogTdrModel{Path: "/v1/report/daily", Request: "a", Response: "b"})
+	assert.Equal(SkippedBodyMarker, model.Response)
+
+	model, _ = filter.apply(LogTdrModel{Path: "/v1/payment", Header: map[string]interface{}{"Content-Type": []string{"application/json"}}, Request: "a", Response: "b"})
+	assert.Equal("a", model.Request)
+	assert.Equal("b", model.Response)
+}
+
+func TestTdrSampling(t *testing.T) {
+	assert := assert.New(t)
+
+	filter := newTdrFilter(TdrOptions{Sampling: TdrSampling{
+		Enabled: true,
+		Rate:    0,
+		Routes:  map[string]float64{"/v1/payment": 1},
+	}})
+
+	_, ok := filter.apply(LogTdrModel{Path: "/v1/inquiry", ResponseCode: "00"})
+	assert.False(ok)
+
+	_, ok = filter.apply(LogTdrModel{Path: "/v1/payment/confirm", ResponseCode: "00"})
+	assert.True(ok)
+
+	_, ok = filter.apply(LogTdrModel{Path: "/v1/inquiry", ResponseCode: "99"})
+	assert.True(ok)
+
+	_, ok = filter.apply(LogTdrModel{Path: "/v1/inquiry", Error: "timeout"})
+	assert.True(ok)
+}
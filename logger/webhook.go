@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"bytes"
+	standardJSON "encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookOptions generic JSON webhook notifier configuration
+type WebhookOptions struct {
+	URL        string            `json:"url"`
+	Timeout    time.Duration     `json:"timeout"`
+	Headers    map[string]string `json:"headers"`
+	AppName    string            `json:"appName"`
+	AppVersion string            `json:"appVersion"`
+}
+
+type webhookPayload struct {
+	AppName    string `json:"app"`
+	AppVersion string `json:"ver"`
+	Entry
+}
+
+type webhook struct {
+	options WebhookOptions
+	client  *http.Client
+}
+
+// NewWebhook create a hook posting every alert entry as JSON to options.URL
+func NewWebhook(options WebhookOptions) Hook {
+	if options.Timeout <= 0 {
+		options.Timeout = 5 * time.Second
+	}
+
+	return &webhook{
+		options: options,
+		client:  &http.Client{Timeout: options.Timeout},
+	}
+}
+
+func (w *webhook) Fire(entry Entry) error {
+	body, err := standardJSON.Marshal(webhookPayload{
+		AppName:    w.options.AppName,
+		AppVersion: w.options.AppVersion,
+		Entry:      entry,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling webhook payload : %+v", err)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, w.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request : %+v", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range w.options.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error sending webhook : %+v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
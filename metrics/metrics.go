@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	DefaultURL = "/metrics"
+
+	SubsystemHTTP = "http"
+	SubsystemGRPC = "grpc"
+)
+
+// DefaultBuckets latency histogram buckets in seconds
+var DefaultBuckets = prometheus.DefBuckets
+
+type Option func(*Metrics)
+
+func WithNamespace(namespace string) Option {
+	return func(m *Metrics) {
+		m.namespace = namespace
+	}
+}
+
+// WithRegistry collectors registered and served by this registry instead of the prometheus default registry
+func WithRegistry(registry *prometheus.Registry) Option {
+	return func(m *Metrics) {
+		if registry != nil {
+			m.registerer = registry
+			m.gatherer = registry
+		}
+	}
+}
+
+func WithBuckets(buckets []float64) Option {
+	return func(m *Metrics) {
+		if len(buckets) > 0 {
+			m.buckets = buckets
+		}
+	}
+}
+
+// WithRuntime registers the Go runtime and process collectors, the default registry already has them
+func WithRuntime(enabled bool) Option {
+	return func(m *Metrics) {
+		m.runtime = enabled
+	}
+}
+
+// Metrics request rate, errors and duration (RED) of one transport, labeled by route, method,
+// status (HTTP or gRPC) and code (Response.Status business code)
+type Metrics struct {
+	namespace  string
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+	buckets    []float64
+	runtime    bool
+	requests   *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+}
+
+// New registers the collectors of the subsystem, ex: SubsystemHTTP. Collectors already registered
+// with the same names are reused so New can be called again, ex: when the middleware is reloaded.
+func New(subsystem string, opts []Option) (*Metrics, error) {
+	m := &Metrics{
+		registerer: prometheus.DefaultRegisterer,
+		gatherer:   prometheus.DefaultGatherer,
+		buckets:    DefaultBuckets,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	labels := []string{"route", "method", "status", "code"}
+
+	requests, err := m.register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Subsystem: subsystem,
+		Name:      "requests_total",
+		Help:      "Number of requests.",
+	}, labels))
+	if err != nil {
+		return nil, err
+	}
+	m.requests = requests.(*prometheus.CounterVec)
+
+	failures, err := m.register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Subsystem: subsystem,
+		Name:      "request_errors_total",
+		Help:      "Number of failed requests, error HTTP or gRPC status or business code.",
+	}, labels))
+	if err != nil {
+		return nil, err
+	}
+	m.errors = failures.(*prometheus.CounterVec)
+
+	duration, err := m.register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Subsystem: subsystem,
+		Name:      "request_duration_seconds",
+		Help:      "Latency of requests in seconds.",
+		Buckets:   m.buckets,
+	}, []string{"route", "method"}))
+	if err != nil {
+		return nil, err
+	}
+	m.duration = duration.(*prometheus.HistogramVec)
+
+	if m.runtime {
+		if _, err := m.register(prometheus.NewGoCollector()); err != nil {
+			return nil, err
+		}
+		if _, err := m.register(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{})); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// register returns the collector already registered with the same description instead of an error
+func (m *Metrics) register(collector prometheus.Collector) (prometheus.Collector, error) {
+	if err := m.registerer.Register(collector); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if errors.As(err, &registered) {
+			return registered.ExistingCollector, nil
+		}
+		return nil, err
+	}
+	return collector, nil
+}
+
+// Observe one request, failed requests are also counted as errors
+func (m *Metrics) Observe(route, method, status, code string, failed bool, duration time.Duration) {
+	m.requests.WithLabelValues(route, method, status, code).Inc()
+	if failed {
+		m.errors.WithLabelValues(route, method, status, code).Inc()
+	}
+	m.duration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// Handler serves the metrics of the registry in the prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	m, err := New(SubsystemHTTP, []Option{WithRegistry(registry), WithRuntime(true)})
+	assert.NoError(t, err)
+
+	// collectors are reused
+	reloaded, err := New(SubsystemHTTP, []Option{WithRegistry(registry), WithRuntime(true)})
+	assert.NoError(t, err)
+
+	m.Observe("/payment", "POST", "200", "00", false, 10*time.Millisecond)
+	reloaded.Observe("/payment", "POST", "200", "51", true, 20*time.Millisecond)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.requests.WithLabelValues("/payment", "POST", "200", "00")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.errors.WithLabelValues("/payment", "POST", "200", "51")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.errors.WithLabelValues("/payment", "POST", "200", "00")))
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", DefaultURL, nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	assert.Contains(t, string(body), `http_request_duration_seconds_count{method="POST",route="/payment"} 2`)
+	assert.Contains(t, string(body), "go_goroutines")
+	assert.Contains(t, string(body), "process_cpu_seconds_total")
+}
@@ -0,0 +1,127 @@
+package pagination
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	standardJSON "encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	cursorSeparator = "."
+	kindTime        = "time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor position carried by an opaque cursor, either an offset or the keyset values of every sort field
+// after which to continue
+type Cursor struct {
+	Offset int64         `json:"o,omitempty"`
+	After  []interface{} `json:"a,omitempty"`
+	Kinds  []string      `json:"k,omitempty"` // types of After lost in JSON, ex: time
+	Sort   string        `json:"s,omitempty"`
+}
+
+// Signer encodes cursors as base64url(json).base64url(hmac-sha256)
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+func (s *Signer) Encode(cursor Cursor) (string, error) {
+	if len(cursor.After) > 0 {
+		after := make([]interface{}, len(cursor.After))
+		kinds := make([]string, len(cursor.After))
+		var typed bool
+		for i, value := range cursor.After {
+			after[i] = value
+			if t, ok := value.(time.Time); ok {
+				after[i] = t.UTC().Format(time.RFC3339Nano)
+				kinds[i] = kindTime
+				typed = true
+			}
+		}
+		cursor.After = after
+		if typed {
+			cursor.Kinds = kinds
+		}
+	}
+
+	payload, err := standardJSON.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + cursorSeparator + base64.RawURLEncoding.EncodeToString(s.sign(encoded)), nil
+}
+
+// Decode verifies the signature and returns the cursor, ErrInvalidCursor when it was not issued with this secret
+func (s *Signer) Decode(token string) (cursor Cursor, err error) {
+	parts := strings.SplitN(token, cursorSeparator, 2)
+	if len(parts) != 2 {
+		return cursor, ErrInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, s.sign(parts[0])) {
+		return cursor, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	decoder := standardJSON.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err = decoder.Decode(&cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	if len(cursor.Kinds) > 0 && len(cursor.Kinds) != len(cursor.After) {
+		return cursor, ErrInvalidCursor
+	}
+	for i, value := range cursor.After {
+		var kind string
+		if len(cursor.Kinds) > 0 {
+			kind = cursor.Kinds[i]
+		}
+		cursor.After[i] = restore(value, kind)
+	}
+	return cursor, nil
+}
+
+func (s *Signer) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// restore gives keyset values back the type the database compares them with
+func restore(value interface{}, kind string) interface{} {
+	switch v := value.(type) {
+	case standardJSON.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	case string:
+		if kind == kindTime {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t
+			}
+		}
+	}
+	return value
+}
@@ -0,0 +1,74 @@
+package pagination
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MongoSort sort document of the page, nil without sort
+func (p Page) MongoSort() interface{} {
+	if len(p.Sort) == 0 {
+		return nil
+	}
+
+	sort := bson.D{}
+	for _, s := range p.Sort {
+		order := 1
+		if s.Desc {
+			order = -1
+		}
+		sort = append(sort, bson.E{Key: s.Field, Value: order})
+	}
+	return sort
+}
+
+// MongoSkip documents to skip, zero for keyset pages
+func (p Page) MongoSkip() int64 {
+	if p.Keyset() {
+		return 0
+	}
+	return p.Offset
+}
+
+// MongoFilter adds the keyset condition of the page to filter, an $or of the documents after the cursor keys:
+// {a: {$gt: a}}, {a: a, b: {$gt: b}}, ... ObjectID hex keys of _id are converted back
+func (p Page) MongoFilter(filter interface{}) interface{} {
+	if !p.Keyset() {
+		return filter
+	}
+
+	after := make([]interface{}, len(p.After))
+	for i, value := range p.After {
+		after[i] = value
+		if hex, ok := value.(string); ok && p.Sort[i].Field == "_id" {
+			if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+				after[i] = id
+			}
+		}
+	}
+
+	conditions := make(bson.A, len(p.Sort))
+	for i, sort := range p.Sort {
+		condition := bson.M{}
+		for j := 0; j < i; j++ {
+			condition[p.Sort[j].Field] = after[j]
+		}
+
+		operator := "$gt"
+		if sort.Desc {
+			operator = "$lt"
+		}
+		condition[sort.Field] = bson.M{operator: after[i]}
+		conditions[i] = condition
+	}
+
+	var condition interface{} = bson.M{"$or": conditions}
+	if len(conditions) == 1 {
+		condition = conditions[0]
+	}
+
+	if filter == nil {
+		return condition
+	}
+	return bson.M{"$and": bson.A{filter, condition}}
+}
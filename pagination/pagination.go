@@ -0,0 +1,242 @@
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	Response "github.com/agitdevcenter/gopkg/response"
+)
+
+const (
+	ParamPage   = "page"
+	ParamLimit  = "limit"
+	ParamOffset = "offset"
+	ParamCursor = "cursor"
+	ParamSort   = "sort"
+
+	DefaultLimit = 20
+	MaxLimit     = 100
+	// DefaultTiebreaker unique field appended to every sort, use "_id" with mongo
+	DefaultTiebreaker = "id"
+)
+
+// Sort order on one field, Field is the column or document field resolved from Options.SortFields
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Options accepted parameters for a list endpoint
+type Options struct {
+	DefaultLimit int
+	MaxLimit     int
+	// Sortable parameter names mapped to their column or document field, sort parameters
+	// not listed here are rejected so user input never reaches a query as an identifier.
+	// With SQL the fields are output columns of the query, see Page.SQL for qualified names
+	SortFields  map[string]string
+	DefaultSort []Sort
+	// Unique field appended to the sort so rows with equal sort values keep a stable order
+	// and cursors continue between them, default is DefaultTiebreaker
+	Tiebreaker string
+	// Enables cursor pagination, cursors are signed so clients can not forge offsets or keys
+	Signer *Signer
+}
+
+// Page parsed pagination parameters, apply it with the mongo and sql adapters
+type Page struct {
+	Number int
+	Limit  int
+	Offset int64
+	Sort   []Sort
+	// Keyset values of every sort field taken from the cursor, nil on the first page
+	After []interface{}
+
+	signer *Signer
+}
+
+// ParamError invalid pagination parameter
+type ParamError struct {
+	Param string
+	Value string
+	Rule  string
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("invalid %s %q", e.Param, e.Value)
+}
+
+// Parse reads page, limit, offset, cursor and sort from query parameters.
+// A cursor replaces page, offset and sort since it carries its own position and order.
+func Parse(params url.Values, options Options) (page Page, err error) {
+	if options.DefaultLimit <= 0 {
+		options.DefaultLimit = DefaultLimit
+	}
+	if options.MaxLimit <= 0 {
+		options.MaxLimit = MaxLimit
+	}
+
+	if len(options.Tiebreaker) == 0 {
+		options.Tiebreaker = DefaultTiebreaker
+	}
+
+	page = Page{Number: 1, Limit: options.DefaultLimit, signer: options.Signer}
+	page.Sort = tiebreak(options.DefaultSort, options.Tiebreaker)
+
+	if value := params.Get(ParamLimit); len(value) > 0 {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return page, &ParamError{Param: ParamLimit, Value: value, Rule: "min"}
+		}
+		if limit > options.MaxLimit {
+			return page, &ParamError{Param: ParamLimit, Value: value, Rule: "max"}
+		}
+		page.Limit = limit
+	}
+
+	if value := params.Get(ParamCursor); len(value) > 0 {
+		if options.Signer == nil {
+			return page, &ParamError{Param: ParamCursor, Value: value, Rule: "cursor"}
+		}
+		cursor, err := options.Signer.Decode(value)
+		if err != nil || (len(cursor.After) > 0 && len(cursor.After) != len(cursorSort(cursor.Sort))) {
+			return page, &ParamError{Param: ParamCursor, Value: value, Rule: "cursor"}
+		}
+		page.Sort = cursorSort(cursor.Sort)
+		page.Offset = cursor.Offset
+		page.After = cursor.After
+		return page, nil
+	}
+
+	if value := params.Get(ParamSort); len(value) > 0 {
+		sorts, err := parseSort(value, options.SortFields)
+		if err != nil {
+			return page, &ParamError{Param: ParamSort, Value: value, Rule: "oneof"}
+		}
+		page.Sort = tiebreak(sorts, options.Tiebreaker)
+	}
+
+	if value := params.Get(ParamOffset); len(value) > 0 {
+		offset, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || offset < 0 {
+			return page, &ParamError{Param: ParamOffset, Value: value, Rule: "min"}
+		}
+		page.Offset = offset
+		page.Number = int(offset)/page.Limit + 1
+		return page, nil
+	}
+
+	if value := params.Get(ParamPage); len(value) > 0 {
+		number, err := strconv.Atoi(value)
+		if err != nil || number <= 0 {
+			return page, &ParamError{Param: ParamPage, Value: value, Rule: "min"}
+		}
+		page.Number = number
+		page.Offset = int64(number-1) * int64(page.Limit)
+	}
+
+	return page, nil
+}
+
+// Keyset true when the page continues after a key instead of skipping rows
+func (p Page) Keyset() bool {
+	return len(p.After) > 0 && len(p.After) == len(p.Sort)
+}
+
+// Next cursor for the page following a result of count items, last are the values of every field of
+// Sort on the last item in the same order, the tiebreaker included. Without last the cursor continues
+// by offset. Empty when there is no next page.
+func (p Page) Next(count int, last ...interface{}) (string, error) {
+	if p.signer == nil || count < p.Limit {
+		return "", nil
+	}
+
+	cursor := Cursor{Sort: formatSort(p.Sort)}
+	if len(last) > 0 {
+		if len(last) != len(p.Sort) {
+			return "", fmt.Errorf("cursor needs %d keys for sort %q, got %d", len(p.Sort), cursor.Sort, len(last))
+		}
+		cursor.After = last
+	} else {
+		cursor.Offset = p.Offset + int64(count)
+	}
+
+	return p.signer.Encode(cursor)
+}
+
+// Meta response metadata of the page, total is ignored when negative, last as in Next
+func (p Page) Meta(total int64, count int, last ...interface{}) (meta Response.Meta, err error) {
+	meta = Response.Meta{Limit: p.Limit, Count: count}
+
+	if p.signer == nil {
+		meta.Page = p.Number
+	}
+
+	if total >= 0 {
+		meta.Total = &total
+		if p.signer == nil && p.Limit > 0 {
+			meta.TotalPages = int((total + int64(p.Limit) - 1) / int64(p.Limit))
+		}
+	}
+
+	meta.NextCursor, err = p.Next(count, last...)
+	return
+}
+
+// parseSort reads "-created_at,name", a leading minus sorts descending
+func parseSort(value string, fields map[string]string) (sorts []Sort, err error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+")
+
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("sort field %q is not allowed", name)
+		}
+		sorts = append(sorts, Sort{Field: field, Desc: desc})
+	}
+
+	return sorts, nil
+}
+
+// tiebreak appends the unique field to sorts unless they already sort on it
+func tiebreak(sorts []Sort, field string) []Sort {
+	for _, sort := range sorts {
+		if sort.Field == field {
+			return sorts
+		}
+	}
+
+	// follows the direction of the last field so SQL can compare the keys as one row
+	desc := len(sorts) > 0 && sorts[len(sorts)-1].Desc
+	return append(append([]Sort{}, sorts...), Sort{Field: field, Desc: desc})
+}
+
+// formatSort writes resolved fields, cursors are signed so cursorSort trusts them without the mapping
+func formatSort(sorts []Sort) string {
+	parts := make([]string, len(sorts))
+	for i, sort := range sorts {
+		parts[i] = sort.Field
+		if sort.Desc {
+			parts[i] = "-" + parts[i]
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func cursorSort(value string) (sorts []Sort) {
+	if len(value) == 0 {
+		return nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		sorts = append(sorts, Sort{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")})
+	}
+	return sorts
+}
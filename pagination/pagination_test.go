@@ -0,0 +1,219 @@
+package pagination
+
+import (
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var sortFields = map[string]string{"id": "id", "createdAt": "created_at"}
+
+func TestParseOffset(t *testing.T) {
+	page, err := Parse(url.Values{"page": {"3"}, "limit": {"10"}, "sort": {"-createdAt,id"}}, Options{SortFields: sortFields})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.Number)
+	assert.Equal(t, int64(20), page.Offset)
+	assert.Equal(t, []Sort{{Field: "created_at", Desc: true}, {Field: "id"}}, page.Sort)
+
+	page, err = Parse(url.Values{"sort": {"-createdAt"}}, Options{SortFields: sortFields})
+	assert.NoError(t, err)
+	assert.Equal(t, []Sort{{Field: "created_at", Desc: true}, {Field: "id", Desc: true}}, page.Sort)
+
+	page, err = Parse(url.Values{}, Options{DefaultSort: []Sort{{Field: "name"}}, Tiebreaker: "_id"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Sort{{Field: "name"}, {Field: "_id"}}, page.Sort)
+
+	page, err = Parse(url.Values{}, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLimit, page.Limit)
+	assert.Equal(t, int64(0), page.Offset)
+
+	_, err = Parse(url.Values{"limit": {"500"}}, Options{})
+	assert.Equal(t, &ParamError{Param: ParamLimit, Value: "500", Rule: "max"}, err)
+
+	_, err = Parse(url.Values{"sort": {"password"}}, Options{SortFields: sortFields})
+	assert.Equal(t, &ParamError{Param: ParamSort, Value: "password", Rule: "oneof"}, err)
+
+	meta, err := Page{Number: 2, Limit: 10, Offset: 10}.Meta(25, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, meta.Page)
+	assert.Equal(t, 3, meta.TotalPages)
+	assert.Empty(t, meta.NextCursor)
+}
+
+func TestCursor(t *testing.T) {
+	options := Options{SortFields: sortFields, Signer: NewSigner("secret")}
+
+	page, err := Parse(url.Values{"limit": {"2"}, "sort": {"-createdAt"}}, options)
+	assert.NoError(t, err)
+
+	last := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err = page.Next(2, last)
+	assert.Error(t, err)
+
+	next, err := page.Next(2, last, int64(7))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, next)
+
+	page, err = Parse(url.Values{"cursor": {next}, "limit": {"2"}}, options)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{last, int64(7)}, page.After)
+	assert.Equal(t, []Sort{{Field: "created_at", Desc: true}, {Field: "id", Desc: true}}, page.Sort)
+	assert.True(t, page.Keyset())
+
+	// a partial page is the last one
+	next, err = page.Next(1, last, int64(9))
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+
+	_, err = Parse(url.Values{"cursor": {next + "x"}}, Options{Signer: NewSigner("other")})
+	assert.Error(t, err)
+
+	offset, err := Page{Limit: 2, Offset: 4, signer: options.Signer}.Next(2)
+	assert.NoError(t, err)
+	cursor, err := options.Signer.Decode(offset)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), cursor.Offset)
+
+	_, err = NewSigner("other").Decode(offset)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestSQL(t *testing.T) {
+	page := Page{Limit: 10, Offset: 20, Sort: []Sort{{Field: "id"}}}
+	query, args := page.SQL("SELECT id FROM users WHERE status = ?;", sqlx.QUESTION, 1)
+	assert.Equal(t, "SELECT * FROM (SELECT id FROM users WHERE status = ?) AS page_query ORDER BY id LIMIT ? OFFSET ?", query)
+	assert.Equal(t, []interface{}{1, 10, int64(20)}, args)
+
+	page = Page{Limit: 10, Sort: []Sort{{Field: "id", Desc: true}}, After: []interface{}{int64(42)}}
+	query, args = page.SQL("SELECT id FROM users WHERE status = $1", sqlx.DOLLAR, 1)
+	assert.Equal(t, "SELECT * FROM (SELECT id FROM users WHERE status = $1) AS page_query WHERE id < $2 ORDER BY id DESC LIMIT $3", query)
+	assert.Equal(t, []interface{}{1, int64(42), 10}, args)
+
+	page = Page{Limit: 10, Sort: []Sort{{Field: "u.created_at"}, {Field: "u.id"}}, After: []interface{}{"2020-01-02", int64(42)}}
+	query, args = page.SQL("SELECT u.id, u.created_at FROM users u", sqlx.QUESTION)
+	assert.Equal(t, "SELECT * FROM (SELECT u.id, u.created_at FROM users u) AS page_query WHERE (created_at, id) > (?, ?) ORDER BY created_at, id LIMIT ?", query)
+	assert.Equal(t, []interface{}{"2020-01-02", int64(42), 10}, args)
+
+	page = Page{Limit: 10, Sort: []Sort{{Field: "amount", Desc: true}, {Field: "id"}}, After: []interface{}{100, int64(42)}}
+	query, args = page.SQL("SELECT id, amount FROM orders", sqlx.DOLLAR)
+	assert.Equal(t, "SELECT * FROM (SELECT id, amount FROM orders) AS page_query WHERE ((amount < $1) OR (amount = $2 AND id > $3)) ORDER BY amount DESC, id LIMIT $4", query)
+	assert.Equal(t, []interface{}{100, 100, int64(42), 10}, args)
+}
+
+func TestMongoFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	page := Page{Limit: 10, Sort: []Sort{{Field: "amount", Desc: true}, {Field: "_id"}}, After: []interface{}{100, id.Hex()}}
+	assert.Equal(t, bson.M{"$and": bson.A{
+		bson.M{"status": 1},
+		bson.M{"$or": bson.A{
+			bson.M{"amount": bson.M{"$lt": 100}},
+			bson.M{"amount": 100, "_id": bson.M{"$gt": id}},
+		}},
+	}}, page.MongoFilter(bson.M{"status": 1}))
+
+	page = Page{Limit: 10, Sort: []Sort{{Field: "_id"}}, After: []interface{}{id.Hex()}}
+	assert.Equal(t, bson.M{"_id": bson.M{"$gt": id}}, page.MongoFilter(nil))
+}
+
+// TestKeysetTies pages through rows sharing their sort value across the page boundaries,
+// the rows after each cursor are selected by evaluating the mongo filter of the page
+func TestKeysetTies(t *testing.T) {
+	type row struct {
+		id     int64
+		amount int64
+	}
+	rows := []row{{1, 300}, {2, 200}, {3, 200}, {4, 200}, {5, 200}, {6, 100}, {7, 100}}
+	options := Options{SortFields: map[string]string{"amount": "amount"}, Signer: NewSigner("secret")}
+
+	for _, order := range []string{"-amount", "amount"} {
+		params := url.Values{"limit": {"2"}, "sort": {order}}
+		var seen []int64
+		for i := 0; i < len(rows); i++ {
+			page, err := Parse(params, options)
+			assert.NoError(t, err)
+
+			var selected []row
+			for _, r := range rows {
+				document := bson.M{"amount": r.amount, "id": r.id}
+				if matches(t, document, page.MongoFilter(nil)) {
+					selected = append(selected, r)
+				}
+			}
+			sort.Slice(selected, func(a, b int) bool {
+				for _, s := range page.Sort {
+					x, y := selected[a].amount, selected[b].amount
+					if s.Field == "id" {
+						x, y = selected[a].id, selected[b].id
+					}
+					if x != y {
+						return (x < y) != s.Desc
+					}
+				}
+				return false
+			})
+			if len(selected) > page.Limit {
+				selected = selected[:page.Limit]
+			}
+
+			for _, r := range selected {
+				seen = append(seen, r.id)
+			}
+			if len(selected) == 0 {
+				break
+			}
+
+			last := selected[len(selected)-1]
+			next, err := page.Next(len(selected), last.amount, last.id)
+			assert.NoError(t, err)
+			if len(next) == 0 {
+				break
+			}
+			params = url.Values{"limit": {"2"}, "cursor": {next}}
+		}
+
+		assert.ElementsMatch(t, []int64{1, 2, 3, 4, 5, 6, 7}, seen, order)
+		assert.Len(t, seen, len(rows), order)
+	}
+}
+
+// matches evaluates the equality, $gt, $lt, $and and $or operators of the keyset filters on int64 fields
+func matches(t *testing.T, document bson.M, filter interface{}) bool {
+	if filter == nil {
+		return true
+	}
+
+	for key, value := range filter.(bson.M) {
+		switch key {
+		case "$and", "$or":
+			any := false
+			all := true
+			for _, condition := range value.(bson.A) {
+				matched := matches(t, document, condition)
+				any = any || matched
+				all = all && matched
+			}
+			if (key == "$and" && !all) || (key == "$or" && !any) {
+				return false
+			}
+		default:
+			field := document[key].(int64)
+			if operators, ok := value.(bson.M); ok {
+				for operator, operand := range operators {
+					if (operator == "$gt" && field <= operand.(int64)) || (operator == "$lt" && field >= operand.(int64)) {
+						return false
+					}
+				}
+			} else if field != value.(int64) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package pagination
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SQL wraps query in a derived table filtered by the keyset condition, ordered by the page sort and
+// limited to the page, bindType is sqlx.QUESTION for mysql and sqlx.DOLLAR for postgres.
+// The sort applies to the columns of the derived table, so sort on the output column names of query:
+// a qualified field like u.created_at is sorted on its column created_at, alias the columns whose
+// name is ambiguous or computed. Sort columns should not be NULL, keyset conditions skip NULL rows.
+func (p Page) SQL(query string, bindType int, args ...interface{}) (string, []interface{}) {
+	args = append([]interface{}{}, args...)
+	placeholder := func(value interface{}) string {
+		args = append(args, value)
+		if bindType == sqlx.DOLLAR {
+			return "$" + strconv.Itoa(len(args))
+		}
+		return "?"
+	}
+
+	var builder strings.Builder
+	builder.WriteString("SELECT * FROM (")
+	builder.WriteString(strings.TrimRight(strings.TrimSpace(query), ";"))
+	builder.WriteString(") AS page_query")
+
+	if p.Keyset() {
+		builder.WriteString(" WHERE " + p.keysetSQL(placeholder))
+	}
+
+	if len(p.Sort) > 0 {
+		orders := make([]string, len(p.Sort))
+		for i, sort := range p.Sort {
+			orders[i] = column(sort.Field)
+			if sort.Desc {
+				orders[i] += " DESC"
+			}
+		}
+		builder.WriteString(" ORDER BY " + strings.Join(orders, ", "))
+	}
+
+	builder.WriteString(" LIMIT " + placeholder(p.Limit))
+	if !p.Keyset() && p.Offset > 0 {
+		builder.WriteString(" OFFSET " + placeholder(p.Offset))
+	}
+
+	return builder.String(), args
+}
+
+// keysetSQL condition of the rows after the cursor keys, a row comparison (a, b, id) > (?, ?, ?) when every
+// field has the same direction, otherwise (a > ? OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id < ?))
+func (p Page) keysetSQL(placeholder func(value interface{}) string) string {
+	if len(p.Sort) == 1 {
+		return column(p.Sort[0].Field) + operator(p.Sort[0]) + placeholder(p.After[0])
+	}
+
+	sameDirection := true
+	for _, sort := range p.Sort {
+		sameDirection = sameDirection && sort.Desc == p.Sort[0].Desc
+	}
+
+	if sameDirection {
+		columns := make([]string, len(p.Sort))
+		values := make([]string, len(p.Sort))
+		for i, sort := range p.Sort {
+			columns[i] = column(sort.Field)
+			values[i] = placeholder(p.After[i])
+		}
+		return "(" + strings.Join(columns, ", ") + ")" + operator(p.Sort[0]) + "(" + strings.Join(values, ", ") + ")"
+	}
+
+	conditions := make([]string, len(p.Sort))
+	for i, sort := range p.Sort {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, column(p.Sort[j].Field)+" = "+placeholder(p.After[j]))
+		}
+		parts = append(parts, column(sort.Field)+operator(sort)+placeholder(p.After[i]))
+		conditions[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+func operator(sort Sort) string {
+	if sort.Desc {
+		return " < "
+	}
+	return " > "
+}
+
+// column name of field in the derived table, the qualifier of the inner query is out of scope
+func column(field string) string {
+	if index := strings.LastIndex(field, "."); index >= 0 {
+		return field[index+1:]
+	}
+	return field
+}
+
+// CountSQL counts the rows of query, use it for the total of the page meta
+func CountSQL(query string) string {
+	return "SELECT COUNT(*) FROM (" + strings.TrimRight(strings.TrimSpace(query), ";") + ") AS count_query"
+}
@@ -0,0 +1,244 @@
+package response
+
+import (
+	standardJSON "encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	Error "github.com/agitdevcenter/gopkg/error"
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v2"
+)
+
+const DefaultLanguage = "en"
+
+// Code catalogue entry, GRPCCode is a code name (NOT_FOUND, NotFound) or number
+type Code struct {
+	Code       string            `json:"code" yaml:"code"`
+	HTTPStatus int               `json:"httpStatus" yaml:"httpStatus"`
+	GRPCCode   string            `json:"grpcCode" yaml:"grpcCode"`
+	Retryable  bool              `json:"retryable" yaml:"retryable"`
+	Messages   map[string]string `json:"messages" yaml:"messages"`
+}
+
+// catalogueFile layout of catalogue files
+type catalogueFile struct {
+	Codes []Code `json:"codes" yaml:"codes"`
+}
+
+// Catalogue response codes with their HTTP status, gRPC code and messages per language
+type Catalogue struct {
+	codes           map[string]Code
+	defaultLanguage string
+}
+
+// DefaultCodes codes of this package
+var DefaultCodes = []Code{
+	{Code: SuccessCode, HTTPStatus: 200, GRPCCode: "OK", Messages: map[string]string{"en": "Success", "id": "Sukses"}},
+	{Code: ErrorInvalidRequest, HTTPStatus: 400, GRPCCode: "INVALID_ARGUMENT", Messages: map[string]string{"en": "Invalid Request", "id": "Permintaan Tidak Valid"}},
+	{Code: ErrorInvalidJson, HTTPStatus: 400, GRPCCode: "INVALID_ARGUMENT", Messages: map[string]string{"en": "Invalid JSON", "id": "JSON Tidak Valid"}},
+	{Code: GeneralError, HTTPStatus: 500, GRPCCode: "INTERNAL", Messages: map[string]string{"en": "General Error", "id": "Kesalahan Umum"}},
+}
+
+var catalogue = struct {
+	sync.RWMutex
+	*Catalogue
+}{Catalogue: mustCatalogue(DefaultCodes)}
+
+// NewCatalogue validated catalogue of codes, every code needs a message in DefaultLanguage
+func NewCatalogue(codes []Code) (*Catalogue, error) {
+	c := &Catalogue{codes: make(map[string]Code, len(codes)), defaultLanguage: DefaultLanguage}
+
+	var problems []string
+	for i, code := range codes {
+		if len(code.Code) == 0 {
+			problems = append(problems, fmt.Sprintf("entry %d has no code", i))
+			continue
+		}
+		if _, ok := c.codes[code.Code]; ok {
+			problems = append(problems, fmt.Sprintf("code %s is duplicated", code.Code))
+		}
+		if code.HTTPStatus != 0 && (code.HTTPStatus < 100 || code.HTTPStatus > 599) {
+			problems = append(problems, fmt.Sprintf("code %s has invalid http status %d", code.Code, code.HTTPStatus))
+		}
+		if _, err := parseGRPCCode(code.GRPCCode); err != nil {
+			problems = append(problems, fmt.Sprintf("code %s has %s", code.Code, err))
+		}
+		if len(code.Messages[c.defaultLanguage]) == 0 {
+			problems = append(problems, fmt.Sprintf("code %s has no %s message", code.Code, c.defaultLanguage))
+		}
+
+		messages := make(map[string]string, len(code.Messages))
+		for language, message := range code.Messages {
+			messages[normalizeLanguage(language)] = message
+		}
+		code.Messages = messages
+		c.codes[code.Code] = code
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid response code catalogue: %s", strings.Join(problems, "; "))
+	}
+
+	return c, nil
+}
+
+// LoadCatalogue reads and validates YAML or JSON catalogue files, DefaultCodes are included
+// and can be overridden by the files, later files override earlier ones
+func LoadCatalogue(paths ...string) (*Catalogue, error) {
+	merged := make(map[string]Code)
+	order := []string{}
+	add := func(code Code) {
+		if _, ok := merged[code.Code]; !ok {
+			order = append(order, code.Code)
+		}
+		merged[code.Code] = code
+	}
+
+	for _, code := range DefaultCodes {
+		add(code)
+	}
+
+	var codes []Code
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var file catalogueFile
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			err = yaml.UnmarshalStrict(data, &file)
+		case ".json":
+			err = standardJSON.Unmarshal(data, &file)
+		default:
+			err = fmt.Errorf("unsupported catalogue format %s", filepath.Ext(path))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		// duplicates within one file are reported by NewCatalogue
+		seen := make(map[string]bool)
+		for _, code := range file.Codes {
+			if seen[code.Code] {
+				codes = append(codes, code)
+				continue
+			}
+			seen[code.Code] = true
+			add(code)
+		}
+	}
+
+	for _, key := range order {
+		codes = append(codes, merged[key])
+	}
+
+	return NewCatalogue(codes)
+}
+
+// SetCatalogue replaces the catalogue used by the package lookups, its codes are not error definitions
+// until Register is called
+func SetCatalogue(c *Catalogue) {
+	catalogue.Lock()
+	catalogue.Catalogue = c
+	catalogue.Unlock()
+}
+
+// Register adds the codes as error definitions so errors of these codes map to their HTTP status and gRPC code,
+// DefaultCodes included: errors of GeneralError answer HTTP 500 and those of ErrorInvalidRequest HTTP 400
+func (c *Catalogue) Register() {
+	definitions := make([]Error.Definition, 0, len(c.codes))
+	for _, code := range c.codes {
+		grpcCode, _ := parseGRPCCode(code.GRPCCode)
+		definitions = append(definitions, Error.Definition{
+			Code:       code.Code,
+			Message:    code.Messages[c.defaultLanguage],
+			HTTPStatus: code.HTTPStatus,
+			GRPCCode:   grpcCode,
+			Retryable:  code.Retryable,
+		})
+	}
+	Error.Register(definitions...)
+}
+
+func (c *Catalogue) Lookup(code string) (Code, bool) {
+	entry, ok := c.codes[code]
+	return entry, ok
+}
+
+// Message of code in the first available language, ex: id_ID, id, then DefaultLanguage. Empty for unknown codes
+func (c *Catalogue) Message(code string, languages ...string) string {
+	entry, ok := c.codes[code]
+	if !ok {
+		return ""
+	}
+
+	for _, language := range languages {
+		language = normalizeLanguage(language)
+		if message, ok := entry.Messages[language]; ok {
+			return message
+		}
+		if index := strings.Index(language, "_"); index > 0 {
+			if message, ok := entry.Messages[language[:index]]; ok {
+				return message
+			}
+		}
+	}
+
+	return entry.Messages[c.defaultLanguage]
+}
+
+// Lookup code in the catalogue set with SetCatalogue
+func Lookup(code string) (Code, bool) {
+	catalogue.RLock()
+	defer catalogue.RUnlock()
+	return catalogue.Lookup(code)
+}
+
+// Message of code in the catalogue set with SetCatalogue
+func Message(code string, languages ...string) string {
+	catalogue.RLock()
+	defer catalogue.RUnlock()
+	return catalogue.Message(code, languages...)
+}
+
+func mustCatalogue(codes []Code) *Catalogue {
+	c, err := NewCatalogue(codes)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func normalizeLanguage(language string) string {
+	return strings.ToLower(strings.Replace(language, "-", "_", -1))
+}
+
+// parseGRPCCode accepts empty, numbers, NOT_FOUND and NotFound
+func parseGRPCCode(value string) (codes.Code, error) {
+	if len(value) == 0 {
+		return codes.OK, nil
+	}
+
+	if number, err := strconv.ParseUint(value, 10, 32); err == nil {
+		if number > uint64(codes.Unauthenticated) {
+			return codes.OK, fmt.Errorf("invalid grpc code %s", value)
+		}
+		return codes.Code(number), nil
+	}
+
+	normalized := strings.ToLower(strings.Replace(value, "_", "", -1))
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if strings.ToLower(code.String()) == normalized {
+			return code, nil
+		}
+	}
+
+	return codes.OK, fmt.Errorf("invalid grpc code %s", value)
+}
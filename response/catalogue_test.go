@@ -0,0 +1,71 @@
+package response
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	Error "github.com/agitdevcenter/gopkg/error"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCatalogue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalogue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	yamlPath := writeFile(t, dir, "codes.yaml", `
+codes:
+  - code: "14"
+    httpStatus: 404
+    grpcCode: NOT_FOUND
+    messages:
+      en: Account not found
+      id: Rekening tidak ditemukan
+`)
+	jsonPath := writeFile(t, dir, "codes.json", `{"codes": [{"code": "51", "httpStatus": 422, "grpcCode": "FailedPrecondition", "retryable": true, "messages": {"en": "Insufficient balance"}}]}`)
+
+	catalogue, err := LoadCatalogue(yamlPath, jsonPath)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Rekening tidak ditemukan", catalogue.Message("14", "id_id", "id"))
+	assert.Equal(t, "Account not found", catalogue.Message("14", "fr"))
+	assert.Equal(t, "Insufficient balance", catalogue.Message("51", "id"))
+	assert.Equal(t, "Sukses", catalogue.Message(SuccessCode, "id"))
+	assert.Empty(t, catalogue.Message("unknown"))
+
+	SetCatalogue(catalogue)
+	defer SetCatalogue(mustCatalogue(DefaultCodes))
+
+	assert.Equal(t, "Account not found", Message("14"))
+	// the codes are error definitions once registered
+	assert.Equal(t, 0, Error.HTTPStatus(Error.New("14", "")))
+
+	catalogue.Register()
+	assert.Equal(t, http.StatusNotFound, Error.HTTPStatus(Error.New("14", "")))
+	assert.Equal(t, codes.FailedPrecondition, Error.GRPCCode(Error.New("51", "")))
+	assert.True(t, Error.IsRetryable(Error.New("51", "")))
+}
+
+func TestCatalogueValidation(t *testing.T) {
+	_, err := NewCatalogue([]Code{
+		{Code: "01", HTTPStatus: 200, Messages: map[string]string{"en": "ok"}},
+		{Code: "01", HTTPStatus: 200, Messages: map[string]string{"en": "ok"}},
+		{Code: "02", HTTPStatus: 1000, GRPCCode: "NOPE", Messages: map[string]string{"id": "oke"}},
+	})
+	assert.EqualError(t, err, "invalid response code catalogue: code 01 is duplicated; "+
+		"code 02 has invalid http status 1000; code 02 has invalid grpc code NOPE; code 02 has no en message")
+}
@@ -1,12 +1,15 @@
 package response
 
+import "encoding/xml"
+
 type Response struct {
-	Status  string `valid:"Required" json:"status"`
-	Message string `valid:"Required" json:"message"`
+	Status  string `valid:"Required" json:"status" xml:"status"`
+	Message string `valid:"Required" json:"message" xml:"message"`
 }
 
 type DefaultResponse struct {
-	Data interface{} `json:"data"`
+	XMLName xml.Name    `json:"-" xml:"response"`
+	Data    interface{} `json:"data" xml:"data"`
 	Response
 }
 
@@ -21,3 +24,27 @@ func CreateResponse(status string, message string, data interface{}) (response D
 
 	return
 }
+
+// Meta pagination metadata, Page and TotalPages are set for offset pages and NextCursor for cursor pages
+type Meta struct {
+	Page       int    `json:"page,omitempty" xml:"page,omitempty"`
+	Limit      int    `json:"limit" xml:"limit"`
+	Count      int    `json:"count" xml:"count"`
+	Total      *int64 `json:"total,omitempty" xml:"total,omitempty"`
+	TotalPages int    `json:"totalPages,omitempty" xml:"totalPages,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty" xml:"nextCursor,omitempty"`
+}
+
+type PaginatedResponse struct {
+	DefaultResponse
+	Meta Meta `json:"meta" xml:"meta"`
+}
+
+func CreatePaginatedResponse(status string, message string, data interface{}, meta Meta) (response PaginatedResponse) {
+	response = PaginatedResponse{
+		DefaultResponse: CreateResponse(status, message, data),
+		Meta:            meta,
+	}
+
+	return
+}
@@ -9,4 +9,5 @@ type Options struct {
 	WithProxy    bool          `json:"withProxy"`
 	ProxyAddress string        `json:"proxyAddress"`
 	SkipTLS      bool          `json:"skipTLS"`
+	BaggageKeys  []string      `json:"baggageKeys"` // session baggage keys sent as request headers
 }
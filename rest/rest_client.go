@@ -1,14 +1,19 @@
 package rest
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/base64"
+	Logger "github.com/agitdevcenter/gopkg/logger"
 	Session "github.com/agitdevcenter/gopkg/session"
+	"github.com/opentracing/opentracing-go"
 	"gopkg.in/resty.v1"
 	"net/http"
 	"time"
 )
 
+const XRequestID = "X-Request-ID"
+
 type RestClient interface {
 	SetAddress(address string)
 	DefaultHeader(username, password string) http.Header
@@ -19,6 +24,14 @@ type RestClient interface {
 	Get(session *Session.Session, path string, headers http.Header) (body []byte, statusCode int, err error)
 	GetWithQueryParam(session *Session.Session, path string, headers http.Header, queryParam map[string]string) (body []byte, statusCode int, err error)
 	Delete(session *Session.Session, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error)
+
+	// context variants read the session stored with session.NewContext and cancel the call with ctx
+	PostContext(ctx context.Context, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error)
+	PostFormDataContext(ctx context.Context, path string, headers http.Header, payload map[string]string) (body []byte, statusCode int, err error)
+	PutContext(ctx context.Context, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error)
+	GetContext(ctx context.Context, path string, headers http.Header) (body []byte, statusCode int, err error)
+	GetWithQueryParamContext(ctx context.Context, path string, headers http.Header, queryParam map[string]string) (body []byte, statusCode int, err error)
+	DeleteContext(ctx context.Context, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error)
 }
 
 func New(options Options) RestClient {
@@ -64,151 +77,98 @@ func (c *client) BasicAuth(username, password string) string {
 }
 
 func (c *client) Post(session *Session.Session, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error) {
-	url := c.options.Address + path
-	processTime := session.T2("Post [request]", url, payload)
-
-	request := c.httpClient.R()
-	request.SetBody(payload)
-
-	for h, val := range headers {
-		request.Header[h] = val
-	}
-	if headers["Content-Type"] == nil {
-		request.Header.Set("Content-Type", "application/json")
-	}
-	request.Header.Set("User-Agent", "https://linkaja.id")
-
-	httpResp, httpErr := request.Post(url)
-
-	if httpResp != nil {
-		body = httpResp.Body()
-	}
-
-	if httpResp != nil && httpResp.StatusCode() != 0 {
-		statusCode = httpResp.StatusCode()
-	}
-
-	session.T3(processTime, "Post [response]", url, string(body))
-
-	if statusCode == http.StatusOK {
-		return body, statusCode, nil
-	}
-
-	return body, statusCode, httpErr
+	return c.PostContext(Session.NewContext(context.Background(), session), path, headers, payload)
 }
 
 func (c *client) PostFormData(session *Session.Session, path string, headers http.Header, payload map[string]string) (body []byte, statusCode int, err error) {
-	url := c.options.Address + path
-	processTime := session.T2("PostFormData [request]", url, payload)
-
-	request := c.httpClient.R()
-	request.SetFormData(payload)
-
-	for h, val := range headers {
-		request.Header[h] = val
-	}
-	if headers["Content-Type"] == nil {
-		request.Header.Set("Content-Type", "application/json")
-	}
-	request.Header.Set("User-Agent", "https://linkaja.id")
-
-	httpResp, httpErr := request.Post(url)
-
-	if httpResp != nil {
-		body = httpResp.Body()
-	}
+	return c.PostFormDataContext(Session.NewContext(context.Background(), session), path, headers, payload)
+}
 
-	if httpResp != nil && httpResp.StatusCode() != 0 {
-		statusCode = httpResp.StatusCode()
-	}
+func (c *client) Put(session *Session.Session, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error) {
+	return c.PutContext(Session.NewContext(context.Background(), session), path, headers, payload)
+}
 
-	session.T3(processTime, "PostFormData [response]", url, string(body))
+func (c *client) Get(session *Session.Session, path string, headers http.Header) (body []byte, statusCode int, err error) {
+	return c.GetContext(Session.NewContext(context.Background(), session), path, headers)
+}
 
-	if statusCode == http.StatusOK {
-		return body, statusCode, nil
-	}
+func (c *client) GetWithQueryParam(session *Session.Session, path string, headers http.Header, queryParam map[string]string) (body []byte, statusCode int, err error) {
+	return c.GetWithQueryParamContext(Session.NewContext(context.Background(), session), path, headers, queryParam)
+}
 
-	return body, statusCode, httpErr
+func (c *client) Delete(session *Session.Session, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error) {
+	return c.DeleteContext(Session.NewContext(context.Background(), session), path, headers, payload)
 }
 
-func (c *client) Put(session *Session.Session, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error) {
-	url := c.options.Address + path
-	processTime := session.T2("Put [request]", url, payload)
+func (c *client) PostContext(ctx context.Context, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error) {
+	return c.execute(ctx, "Post", http.MethodPost, path, headers, true, payload, func(request *resty.Request) {
+		request.SetBody(payload)
+	})
+}
 
-	request := c.httpClient.R()
+func (c *client) PostFormDataContext(ctx context.Context, path string, headers http.Header, payload map[string]string) (body []byte, statusCode int, err error) {
+	return c.execute(ctx, "PostFormData", http.MethodPost, path, headers, true, payload, func(request *resty.Request) {
+		request.SetFormData(payload)
+	})
+}
 
-	for h, val := range headers {
-		request.Header[h] = val
-	}
-	if headers["Content-Type"] == nil {
-		request.Header.Set("Content-Type", "application/json")
-	}
-	request.Header.Set("User-Agent", "https://linkaja.id")
+func (c *client) PutContext(ctx context.Context, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error) {
+	return c.execute(ctx, "Put", http.MethodPut, path, headers, true, payload, func(request *resty.Request) {
+		request.SetBody(payload)
+	})
+}
 
-	request.SetBody(payload)
+func (c *client) GetContext(ctx context.Context, path string, headers http.Header) (body []byte, statusCode int, err error) {
+	return c.execute(ctx, "Get", http.MethodGet, path, headers, false, nil, nil)
+}
 
-	httpResp, httpErr := request.Put(url)
+func (c *client) GetWithQueryParamContext(ctx context.Context, path string, headers http.Header, queryParam map[string]string) (body []byte, statusCode int, err error) {
+	return c.execute(ctx, "Get", http.MethodGet, path, headers, false, nil, func(request *resty.Request) {
+		request.SetQueryParams(queryParam)
+	})
+}
 
-	if httpResp != nil {
-		body = httpResp.Body()
-	}
+func (c *client) DeleteContext(ctx context.Context, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error) {
+	return c.execute(ctx, "Delete", http.MethodDelete, path, headers, false, nil, func(request *resty.Request) {
+		request.SetBody(payload)
+	})
+}
 
-	if httpResp != nil && httpResp.StatusCode() != 0 {
-		statusCode = httpResp.StatusCode()
+func (c *client) execute(ctx context.Context, name, method, path string, headers http.Header, defaultJSON bool, payload interface{}, setup func(request *resty.Request)) (body []byte, statusCode int, err error) {
+	session, ok := Session.FromContext(ctx)
+	if !ok {
+		session = Session.New(Logger.Noop())
 	}
 
-	session.T3(processTime, "Put [response]", url, string(body))
+	url := c.options.Address + path
 
-	if statusCode == http.StatusOK {
-		return body, statusCode, nil
+	var processTime time.Time
+	if payload != nil {
+		processTime = session.T2(name+" [request]", url, payload)
+	} else {
+		processTime = session.T2(name+" [request]", url)
 	}
 
-	return body, statusCode, httpErr
-}
-
-func (c *client) Get(session *Session.Session, path string, headers http.Header) (body []byte, statusCode int, err error) {
-	url := c.options.Address + path
-	processTime := session.T2("Get [request]", url)
-
 	request := c.httpClient.R()
+	if ctx != nil {
+		request.SetContext(ctx)
+	}
 
 	for h, val := range headers {
 		request.Header[h] = val
 	}
-	request.Header.Set("User-Agent", "https://linkaja.id")
-
-	httpResp, httpErr := request.Get(url)
-
-	if httpResp != nil {
-		body = httpResp.Body()
-	}
-
-	if httpResp != nil && httpResp.StatusCode() != 0 {
-		statusCode = httpResp.StatusCode()
-	}
-
-	session.T3(processTime, "Get [response]", url, string(body))
-
-	if statusCode == http.StatusOK {
-		return body, statusCode, nil
+	if defaultJSON && headers["Content-Type"] == nil {
+		request.Header.Set("Content-Type", "application/json")
 	}
+	request.Header.Set("User-Agent", "https://linkaja.id")
 
-	return body, statusCode, httpErr
-}
-
-func (c *client) GetWithQueryParam(session *Session.Session, path string, headers http.Header, queryParam map[string]string) (body []byte, statusCode int, err error) {
-	url := c.options.Address + path
-	processTime := session.T2("Get [request]", url)
+	c.propagate(ctx, session, request.Header)
 
-	request := c.httpClient.R()
-
-	for h, val := range headers {
-		request.Header[h] = val
+	if setup != nil {
+		setup(request)
 	}
-	request.Header.Set("User-Agent", "https://linkaja.id")
-	request.SetQueryParams(queryParam)
 
-	httpResp, httpErr := request.Get(url)
+	httpResp, httpErr := request.Execute(method, url)
 
 	if httpResp != nil {
 		body = httpResp.Body()
@@ -218,7 +178,7 @@ func (c *client) GetWithQueryParam(session *Session.Session, path string, header
 		statusCode = httpResp.StatusCode()
 	}
 
-	session.T3(processTime, "Get [response]", url, string(body))
+	session.T3(processTime, name+" [response]", url, string(body))
 
 	if statusCode == http.StatusOK {
 		return body, statusCode, nil
@@ -227,34 +187,22 @@ func (c *client) GetWithQueryParam(session *Session.Session, path string, header
 	return body, statusCode, httpErr
 }
 
-func (c *client) Delete(session *Session.Session, path string, headers http.Header, payload interface{}) (body []byte, statusCode int, err error) {
-	url := c.options.Address + path
-	processTime := session.T2("Delete [request]", url)
-
-	request := c.httpClient.R()
-
-	for h, val := range headers {
-		request.Header[h] = val
+// propagate sends the thread ID, the tracing span and the configured session baggage downstream
+func (c *client) propagate(ctx context.Context, session *Session.Session, header http.Header) {
+	if len(header.Get(XRequestID)) == 0 && len(session.ThreadID) > 0 {
+		header.Set(XRequestID, session.ThreadID)
 	}
-	request.Header.Set("User-Agent", "https://linkaja.id")
-
-	request.SetBody(payload)
 
-	httpResp, httpErr := request.Delete(url)
-
-	if httpResp != nil {
-		body = httpResp.Body()
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		opentracing.GlobalTracer().Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
 	}
 
-	if httpResp != nil && httpResp.StatusCode() != 0 {
-		statusCode = httpResp.StatusCode()
-	}
-
-	session.T3(processTime, "Delete [response]", url, string(body))
-
-	if statusCode == http.StatusOK {
-		return body, statusCode, nil
+	for _, key := range c.options.BaggageKeys {
+		if len(header.Get(key)) > 0 {
+			continue
+		}
+		if value, ok := session.GetBaggage(key); ok {
+			header.Set(key, value)
+		}
 	}
-
-	return body, statusCode, httpErr
 }
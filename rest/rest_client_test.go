@@ -0,0 +1,183 @@
+package rest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Session "github.com/agitdevcenter/gopkg/session"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// tagRecorder logger keeping the _app_tag of the session logs
+type tagRecorder struct {
+	Logger.Logger
+	mutex sync.Mutex
+	tags  []string
+}
+
+func (r *tagRecorder) Info(message string, fields ...zap.Field) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, field := range fields {
+		if field.Key == "_app_tag" {
+			r.tags = append(r.tags, field.String)
+		}
+	}
+}
+
+func (r *tagRecorder) recorded() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string{}, r.tags...)
+}
+
+type received struct {
+	method, requestID, body string
+}
+
+func newServer(t *testing.T) (*httptest.Server, func() []received) {
+	var mutex sync.Mutex
+	var requests []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		mutex.Lock()
+		requests = append(requests, received{method: r.Method, requestID: r.Header.Get(XRequestID), body: string(body)})
+		mutex.Unlock()
+
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	return server, func() []received {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]received{}, requests...)
+	}
+}
+
+func TestContextCalls(t *testing.T) {
+	server, requests := newServer(t)
+	defer server.Close()
+
+	logger := &tagRecorder{Logger: Logger.Noop()}
+	session := Session.New(logger).SetThreadID("thread-1")
+	ctx := Session.NewContext(context.Background(), session)
+
+	client := New(Options{Address: server.URL, Timeout: 5})
+	calls := []func() ([]byte, int, error){
+		func() ([]byte, int, error) { return client.PostContext(ctx, "/post", nil, map[string]int{"a": 1}) },
+		func() ([]byte, int, error) {
+			return client.PostFormDataContext(ctx, "/form", nil, map[string]string{"a": "1"})
+		},
+		func() ([]byte, int, error) { return client.PutContext(ctx, "/put", nil, map[string]int{"a": 1}) },
+		func() ([]byte, int, error) { return client.GetContext(ctx, "/get", nil) },
+		func() ([]byte, int, error) {
+			return client.GetWithQueryParamContext(ctx, "/query", nil, map[string]string{"a": "1"})
+		},
+		func() ([]byte, int, error) { return client.DeleteContext(ctx, "/delete", nil, nil) },
+	}
+	for _, call := range calls {
+		body, statusCode, err := call()
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, `{"ok":true}`, string(body))
+	}
+
+	got := requests()
+	if assert.Len(t, got, len(calls)) {
+		methods := []string{http.MethodPost, http.MethodPost, http.MethodPut, http.MethodGet, http.MethodGet, http.MethodDelete}
+		for i, request := range got {
+			assert.Equal(t, methods[i], request.method)
+			assert.Equal(t, "thread-1", request.requestID)
+		}
+		assert.Equal(t, `{"a":1}`, got[0].body)
+		assert.Equal(t, "a=1", got[1].body)
+	}
+
+	// every call logged its T2/T3 through the session of the context
+	tags := logger.recorded()
+	assert.Len(t, tags, 2*len(calls))
+	for i := 0; i+1 < len(tags); i += 2 {
+		assert.Equal(t, []string{"T2", "T3"}, tags[i:i+2])
+	}
+}
+
+func TestContextWithoutSession(t *testing.T) {
+	server, requests := newServer(t)
+	defer server.Close()
+
+	client := New(Options{Address: server.URL, Timeout: 5})
+	_, statusCode, err := client.GetContext(context.Background(), "/get", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+
+	got := requests()
+	if assert.Len(t, got, 1) {
+		assert.Empty(t, got[0].requestID)
+	}
+}
+
+func TestContextCanceled(t *testing.T) {
+	server, requests := newServer(t)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(Session.NewContext(context.Background(), Session.New(Logger.Noop())))
+	cancel()
+
+	client := New(Options{Address: server.URL, Timeout: 5})
+	_, _, err := client.GetContext(ctx, "/get", nil)
+	assert.Error(t, err)
+	assert.Empty(t, requests())
+}
+
+func TestPropagate(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	session := Session.New(Logger.Noop()).SetThreadID("thread-1")
+	session.SetBaggage("X-Partner-ID", "partner-1").SetBaggage("X-Channel", "app").SetBaggage("X-Secret", "secret")
+
+	span := tracer.StartSpan("caller")
+	ctx := opentracing.ContextWithSpan(Session.NewContext(context.Background(), session), span)
+
+	client := New(Options{Address: server.URL, Timeout: 5, BaggageKeys: []string{"X-Partner-ID", "X-Channel"}})
+	headers := http.Header{}
+	headers.Set("X-Channel", "web")
+	_, statusCode, err := client.GetContext(ctx, "/get", headers)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+
+	assert.Equal(t, "thread-1", header.Get(XRequestID))
+	assert.Equal(t, "partner-1", header.Get("X-Partner-ID"))
+	// explicit headers win over the baggage, keys not configured stay in the process
+	assert.Equal(t, "web", header.Get("X-Channel"))
+	assert.Empty(t, header.Get("X-Secret"))
+
+	spanContext, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+	if assert.NoError(t, err) {
+		assert.Equal(t, span.Context().(mocktracer.MockSpanContext).TraceID, spanContext.(mocktracer.MockSpanContext).TraceID)
+		assert.Equal(t, span.Context().(mocktracer.MockSpanContext).SpanID, spanContext.(mocktracer.MockSpanContext).SpanID)
+	}
+
+	// an explicit request ID is kept
+	headers = http.Header{}
+	headers.Set(XRequestID, "upstream")
+	_, _, err = client.GetContext(ctx, "/get", headers)
+	assert.NoError(t, err)
+	assert.Equal(t, "upstream", header.Get(XRequestID))
+}
@@ -0,0 +1,23 @@
+package session
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of parent carrying the session pointer, every layer reading it back with
+// FromContext shares the same session so mutations are visible in the final TDR
+func NewContext(parent context.Context, session *Session) context.Context {
+	if parent == nil {
+		parent = context.Background()
+	}
+	return context.WithValue(parent, contextKey{}, session)
+}
+
+// FromContext returns the session stored in ctx by NewContext
+func FromContext(ctx context.Context) (session *Session, ok bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	session, ok = ctx.Value(contextKey{}).(*Session)
+	return session, ok && session != nil
+}
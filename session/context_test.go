@@ -0,0 +1,52 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	session := New(Logger.Noop()).SetThreadID("thread-1")
+
+	ctx := NewContext(context.Background(), session)
+	found, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Same(t, session, found)
+
+	// derived contexts share the same session
+	derived, cancel := context.WithCancel(ctx)
+	defer cancel()
+	found, ok = FromContext(derived)
+	assert.True(t, ok)
+	assert.Same(t, session, found)
+
+	found.SetResponseCode("00")
+	assert.Equal(t, "00", session.ResponseCode)
+
+	// the innermost session wins
+	inner := New(Logger.Noop())
+	found, ok = FromContext(NewContext(ctx, inner))
+	assert.True(t, ok)
+	assert.Same(t, inner, found)
+
+	found, ok = FromContext(NewContext(nil, session))
+	assert.True(t, ok)
+	assert.Same(t, session, found)
+}
+
+func TestContextMissing(t *testing.T) {
+	found, ok := FromContext(context.Background())
+	assert.False(t, ok)
+	assert.Nil(t, found)
+
+	found, ok = FromContext(nil)
+	assert.False(t, ok)
+	assert.Nil(t, found)
+
+	found, ok = FromContext(NewContext(context.Background(), nil))
+	assert.False(t, ok)
+	assert.Nil(t, found)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cast"
 	"go.uber.org/zap"
+	"net/http"
 	"time"
 )
 
@@ -23,6 +24,8 @@ type Session struct {
 	Header, Request         interface{}
 	ErrorMessage            string
 	ResponseCode            string
+	Baggage                 Map.ConcurrentMap
+	timeline                *timeline
 }
 
 func New(logger Logger.Logger) *Session {
@@ -30,8 +33,10 @@ func New(logger Logger.Logger) *Session {
 		RequestTime: time.Now(),
 		Logger:      logger,
 		Map:         Map.New(),
+		Baggage:     Map.New(),
 		Header:      map[string]interface{}{},
 		Request:     struct{}{},
+		timeline:    &timeline{},
 	}
 }
 
@@ -95,6 +100,26 @@ func (session *Session) SetResponseCode(responseCode string) *Session {
 	return session
 }
 
+// SetBaggage set a value propagated to downstream services, key is the HTTP header name
+func (session *Session) SetBaggage(key, value string) *Session {
+	if session.Baggage == nil {
+		session.Baggage = Map.New()
+	}
+	session.Baggage.Set(http.CanonicalHeaderKey(key), value)
+	return session
+}
+
+func (session *Session) GetBaggage(key string) (value string, ok bool) {
+	if session.Baggage == nil {
+		return
+	}
+	var data interface{}
+	if data, ok = session.Baggage.Get(http.CanonicalHeaderKey(key)); ok {
+		value, ok = data.(string)
+	}
+	return
+}
+
 func (session *Session) Get(key string) (data interface{}, err error) {
 	data, ok := session.Map.Get(key)
 	if !ok {
@@ -192,6 +217,7 @@ func (session *Session) T4(message ...interface{}) {
 		ThreadID:       session.ThreadID,
 		AdditionalData: session.Map,
 		ResponseCode:   session.getResponseCode(response),
+		Timeline:       session.Timeline(),
 	})
 }
 
@@ -0,0 +1,130 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+)
+
+// Step a named, timed unit of work recorded on the session timeline and written in the T4 TDR,
+// its result is written under the timeline lock so read it with the accessors
+type Step struct {
+	Name  string
+	Start time.Time
+
+	duration time.Duration
+	err      string
+	steps    []*Step
+	session  *Session
+	ended    bool
+}
+
+type timeline struct {
+	mutex sync.Mutex
+	steps []*Step
+}
+
+// StartStep starts a top level step, close it with End
+func (session *Session) StartStep(name string) *Step {
+	if session.timeline == nil {
+		session.timeline = &timeline{}
+	}
+
+	step := &Step{Name: name, Start: time.Now(), session: session}
+
+	session.timeline.mutex.Lock()
+	session.timeline.steps = append(session.timeline.steps, step)
+	session.timeline.mutex.Unlock()
+
+	session.T2(name)
+	return step
+}
+
+// StartStep starts a step nested in this one
+func (step *Step) StartStep(name string) *Step {
+	session := step.session
+	child := &Step{Name: name, Start: time.Now(), session: session}
+
+	session.timeline.mutex.Lock()
+	step.steps = append(step.steps, child)
+	session.timeline.mutex.Unlock()
+
+	session.T2(name)
+	return child
+}
+
+// End closes the step with its result and error, only the first call is recorded
+func (step *Step) End(result interface{}, err error) {
+	session := step.session
+
+	session.timeline.mutex.Lock()
+	if step.ended {
+		session.timeline.mutex.Unlock()
+		return
+	}
+	step.ended = true
+	step.duration = time.Since(step.Start)
+	if err != nil {
+		step.err = err.Error()
+	}
+	session.timeline.mutex.Unlock()
+
+	if err != nil {
+		session.T3(step.Start, step.Name, err.Error())
+		return
+	}
+	session.T3(step.Start, step.Name, result)
+}
+
+// Duration of the ended step, zero while running
+func (step *Step) Duration() time.Duration {
+	step.session.timeline.mutex.Lock()
+	defer step.session.timeline.mutex.Unlock()
+	return step.duration
+}
+
+// Err message of the error the step ended with
+func (step *Step) Err() string {
+	step.session.timeline.mutex.Lock()
+	defer step.session.timeline.mutex.Unlock()
+	return step.err
+}
+
+// Steps copy of the nested steps started so far
+func (step *Step) Steps() []*Step {
+	step.session.timeline.mutex.Lock()
+	defer step.session.timeline.mutex.Unlock()
+	return append([]*Step(nil), step.steps...)
+}
+
+// Timeline snapshot of the recorded steps, offsets are relative to the session request time
+func (session *Session) Timeline() []Logger.TdrStep {
+	if session.timeline == nil {
+		return nil
+	}
+
+	session.timeline.mutex.Lock()
+	defer session.timeline.mutex.Unlock()
+
+	return session.toTdrSteps(session.timeline.steps, time.Now())
+}
+
+func (session *Session) toTdrSteps(steps []*Step, now time.Time) (result []Logger.TdrStep) {
+	for _, step := range steps {
+		duration := step.duration
+		if !step.ended {
+			duration = now.Sub(step.Start)
+		}
+
+		result = append(result, Logger.TdrStep{
+			Name:     step.Name,
+			Start:    step.Start.Sub(session.RequestTime).Nanoseconds() / 1000000,
+			Duration: duration.Nanoseconds() / 1000000,
+			Error:    step.err,
+			Ended:    step.ended,
+			Steps:    session.toTdrSteps(step.steps, now),
+		})
+	}
+	return
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// tdrRecorder logger keeping the TDRs
+type tdrRecorder struct {
+	Logger.Logger
+	mutex sync.Mutex
+	tdrs  []Logger.LogTdrModel
+}
+
+func (r *tdrRecorder) TDR(tdr Logger.LogTdrModel) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.tdrs = append(r.tdrs, tdr)
+}
+
+func TestStepConcurrent(t *testing.T) {
+	logger := &tdrRecorder{Logger: Logger.Noop()}
+	session := New(logger)
+
+	parent := session.StartStep("parent")
+
+	const workers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := parent.StartStep(fmt.Sprintf("child-%d", i))
+			var err error
+			if i%2 == 1 {
+				err = errors.New("failed")
+			}
+
+			// concurrent End calls, only the first one is kept
+			var ends sync.WaitGroup
+			for j := 0; j < 3; j++ {
+				ends.Add(1)
+				go func() {
+					defer ends.Done()
+					child.End(nil, err)
+				}()
+			}
+
+			// readers while the steps are written
+			_ = parent.Steps()
+			_ = child.Duration()
+			_ = child.Err()
+			_ = session.Timeline()
+			ends.Wait()
+		}(i)
+	}
+	wg.Wait()
+	parent.End("done", nil)
+
+	session.T4("response")
+
+	assert.Len(t, logger.tdrs, 1)
+	timeline := logger.tdrs[0].Timeline
+	if assert.Len(t, timeline, 1) {
+		assert.Equal(t, "parent", timeline[0].Name)
+		assert.True(t, timeline[0].Ended)
+		assert.Empty(t, timeline[0].Error)
+		assert.Len(t, timeline[0].Steps, workers)
+
+		failed := 0
+		for _, child := range timeline[0].Steps {
+			assert.True(t, child.Ended)
+			if child.Error != "" {
+				assert.Equal(t, "failed", child.Error)
+				failed++
+			}
+		}
+		assert.Equal(t, workers/2, failed)
+	}
+
+	for _, child := range parent.Steps() {
+		assert.True(t, child.Duration() >= 0)
+	}
+}
+
+func TestStepEndOnce(t *testing.T) {
+	session := New(Logger.Noop())
+
+	step := session.StartStep("query")
+	step.End(nil, errors.New("timeout"))
+	duration := step.Duration()
+	step.End("ok", nil)
+
+	assert.Equal(t, "timeout", step.Err())
+	assert.Equal(t, duration, step.Duration())
+
+	timeline := session.Timeline()
+	if assert.Len(t, timeline, 1) {
+		assert.Equal(t, "query", timeline[0].Name)
+		assert.Equal(t, "timeout", timeline[0].Error)
+		assert.True(t, timeline[0].Ended)
+	}
+}
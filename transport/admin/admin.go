@@ -0,0 +1,373 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agitdevcenter/gopkg/health"
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/agitdevcenter/gopkg/metrics"
+	Response "github.com/agitdevcenter/gopkg/response"
+	HTTP "github.com/agitdevcenter/gopkg/transport/http"
+	Middleware "github.com/agitdevcenter/gopkg/transport/http/middleware"
+	Echo "github.com/labstack/echo/v4"
+)
+
+const (
+	DefaultHost = "127.0.0.1"
+	DefaultPort = 9102
+
+	InfoURL         = "/info"
+	HealthURL       = "/health"
+	LivenessURL     = "/live"
+	ReadinessURL    = "/ready"
+	AvailabilityURL = "/availability"
+	LogLevelURL     = "/loglevel"
+	ReloadURL       = "/reload"
+	DebugURL        = "/debug/pprof"
+)
+
+// Admin server of the operational endpoints, listening on its own address so they are not exposed
+// with the public router. Every endpoint except the liveness and readiness probes requires the basic auth
+// credentials or the token when one of them is set.
+type Admin struct {
+	debug          bool
+	logger         Logger.Logger
+	host           string
+	port           int
+	serverOptions  []HTTP.Option
+	username       string
+	password       string
+	token          string
+	profiling      bool
+	metrics        *metrics.Metrics
+	healthRegistry *health.Registry
+	middleware     *Middleware.Middleware
+	reloader       func(ctx context.Context) error
+	name           string
+	version        string
+	buildInfo      map[string]string
+	server         *HTTP.Server
+	mutex          sync.RWMutex
+}
+
+func New(opts []Option) *Admin {
+	a := &Admin{
+		host:           DefaultHost,
+		port:           DefaultPort,
+		healthRegistry: health.DefaultRegistry,
+		name:           Middleware.Name,
+		version:        Middleware.Version,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.logger == nil {
+		a.logger = Logger.Noop()
+	}
+
+	a.server = HTTP.New(append([]HTTP.Option{
+		HTTP.WithAddress(a.host, a.port),
+		HTTP.WithRouter(a),
+	}, a.serverOptions...))
+
+	return a
+}
+
+func (a *Admin) SetDebug(enabled bool) {
+	a.debug = enabled
+	a.server.SetDebug(enabled)
+}
+
+// SetLogger logger of the server, its level is served on LogLevelURL when it implements Logger.Leveler
+func (a *Admin) SetLogger(logger Logger.Logger) {
+	a.mutex.Lock()
+	a.logger = logger
+	a.mutex.Unlock()
+	a.server.SetLogger(logger)
+}
+
+func (a *Admin) SetHealthRegistry(registry *health.Registry) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.healthRegistry = registry
+}
+
+// SetMiddleware middleware of the public server, its availability is served on AvailabilityURL
+func (a *Admin) SetMiddleware(middleware *Middleware.Middleware) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.middleware = middleware
+}
+
+// SetReloader called on ReloadURL, the transport sets its Reload to reload every server and service
+func (a *Admin) SetReloader(reloader func(ctx context.Context) error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.reloader = reloader
+}
+
+func (a *Admin) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
+	return a.server.Start(ctx, wg)
+}
+
+// Reload reads the TLS certificate files of the server again
+func (a *Admin) Reload(ctx context.Context) error {
+	return a.server.Reload(ctx)
+}
+
+// SetListener serves on listener instead of listening on the address, ex: a listener inherited from the parent process
+func (a *Admin) SetListener(listener net.Listener) {
+	a.server.SetListener(listener)
+}
+
+// Listener the listener being served, nil before the server is started
+func (a *Admin) Listener() net.Listener {
+	return a.server.Listener()
+}
+
+// Ready closed once the server is listening, see custom.Readiness
+func (a *Admin) Ready() <-chan struct{} {
+	return a.server.Ready()
+}
+
+// Addr address the server is bound to, nil before the server is listening
+func (a *Admin) Addr() net.Addr {
+	return a.server.Addr()
+}
+
+// Route registers the admin endpoints, see router.Router
+func (a *Admin) Route(e *Echo.Echo) {
+	e.HideBanner = true
+	e.HidePort = true
+
+	e.GET(LivenessURL, func(c Echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.GET(ReadinessURL, func(c Echo.Context) error {
+		if a.registry().Check(c.Request().Context()).Status == health.StatusDown {
+			return c.NoContent(http.StatusServiceUnavailable)
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	g := e.Group("", a.authenticate)
+
+	g.GET(InfoURL, a.info)
+	g.GET(HealthURL, a.health)
+	g.Any(AvailabilityURL, a.availability)
+	g.GET(LogLevelURL, a.logLevel)
+	g.PUT(LogLevelURL, a.setLogLevel)
+	g.POST(ReloadURL, a.reload)
+
+	if a.metrics != nil {
+		g.GET(metrics.DefaultURL, Echo.WrapHandler(a.metrics.Handler()))
+	}
+
+	if a.profiling {
+		g.GET(DebugURL+"/*", Echo.WrapHandler(http.HandlerFunc(pprof.Index)))
+		g.GET(DebugURL+"/cmdline", Echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
+		g.GET(DebugURL+"/profile", Echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
+		g.GET(DebugURL+"/symbol", Echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+		g.POST(DebugURL+"/symbol", Echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+		g.GET(DebugURL+"/trace", Echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
+	}
+}
+
+// authenticate accepts the basic auth credentials or the bearer token, every request when none is set
+func (a *Admin) authenticate(next Echo.HandlerFunc) Echo.HandlerFunc {
+	return func(c Echo.Context) error {
+		if len(a.username) == 0 && len(a.token) == 0 {
+			return next(c)
+		}
+
+		if len(a.username) > 0 {
+			if username, password, ok := c.Request().BasicAuth(); ok && equal(username, a.username) && equal(password, a.password) {
+				return next(c)
+			}
+		}
+
+		if len(a.token) > 0 {
+			authorization := c.Request().Header.Get(Echo.HeaderAuthorization)
+			if strings.HasPrefix(authorization, "Bearer ") && equal(strings.TrimPrefix(authorization, "Bearer "), a.token) {
+				return next(c)
+			}
+		}
+
+		if len(a.username) > 0 {
+			c.Response().Header().Set(Echo.HeaderWWWAuthenticate, `Basic realm="admin"`)
+		}
+		return a.respond(c, http.StatusUnauthorized, Response.GeneralError, http.StatusText(http.StatusUnauthorized), nil)
+	}
+}
+
+func equal(value, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(value), []byte(expected)) == 1
+}
+
+func (a *Admin) respond(c Echo.Context, code int, status, message string, data interface{}) error {
+	if data == nil {
+		data = struct{}{}
+	}
+	return c.JSON(code, Response.CreateResponse(status, message, data))
+}
+
+func (a *Admin) registry() *health.Registry {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.healthRegistry
+}
+
+func (a *Admin) info(c Echo.Context) error {
+	data := map[string]interface{}{
+		"name":      a.name,
+		"version":   a.version,
+		"goVersion": runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		data["path"] = build.Main.Path
+		data["module"] = build.Main.Version
+	}
+
+	for key, value := range a.buildInfo {
+		data[key] = value
+	}
+
+	return a.respond(c, http.StatusOK, Response.SuccessCode, "info", data)
+}
+
+func (a *Admin) health(c Echo.Context) error {
+	from := time.Now()
+	report := a.registry().Health(c.Request().Context())
+
+	data := map[string]interface{}{
+		"status":  report.Status,
+		"checks":  report.Checks,
+		"elapsed": time.Since(from).Nanoseconds() / 1000000,
+	}
+
+	switch report.Status {
+	case health.StatusDegraded:
+		return a.respond(c, http.StatusOK, Response.SuccessCode, "degraded", data)
+	case health.StatusDown:
+		return a.respond(c, http.StatusServiceUnavailable, Response.GeneralError, "unhealthy", data)
+	}
+	return a.respond(c, http.StatusOK, Response.SuccessCode, "healthy", data)
+}
+
+// availability serves the availability of the public server middleware, the user is the authenticated one
+func (a *Admin) availability(c Echo.Context) error {
+	a.mutex.RLock()
+	middleware := a.middleware
+	a.mutex.RUnlock()
+
+	if middleware == nil || middleware.Availability() == nil {
+		return a.respond(c, http.StatusNotFound, Response.GeneralError, "the middleware has no availability", nil)
+	}
+
+	middleware.Availability().Handler(a.user).ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// user authenticated by the authenticate middleware
+func (a *Admin) user(r *http.Request) (string, bool) {
+	if username, _, ok := r.BasicAuth(); ok && len(a.username) > 0 {
+		return username, true
+	}
+	if len(a.token) > 0 {
+		return "token", true
+	}
+	return "admin", true
+}
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+func (a *Admin) leveler(c Echo.Context) (Logger.Leveler, error) {
+	a.mutex.RLock()
+	leveler, ok := a.logger.(Logger.Leveler)
+	a.mutex.RUnlock()
+
+	if !ok {
+		return nil, a.respond(c, http.StatusNotFound, Response.GeneralError, "the logger has no level", nil)
+	}
+	return leveler, nil
+}
+
+func (a *Admin) logLevel(c Echo.Context) error {
+	leveler, err := a.leveler(c)
+	if leveler == nil {
+		return err
+	}
+
+	return a.respond(c, http.StatusOK, Response.SuccessCode, http.StatusText(http.StatusOK), logLevelRequest{Level: leveler.Level()})
+}
+
+func (a *Admin) setLogLevel(c Echo.Context) error {
+	leveler, err := a.leveler(c)
+	if leveler == nil {
+		return err
+	}
+
+	var request logLevelRequest
+	if err := c.Bind(&request); err != nil {
+		return a.respond(c, http.StatusBadRequest, Response.GeneralError, err.Error(), nil)
+	}
+
+	if err := leveler.SetLevel(request.Level); err != nil {
+		return a.respond(c, http.StatusBadRequest, Response.GeneralError, err.Error(), nil)
+	}
+
+	a.log(fmt.Sprintf("log level set to %s from %s", leveler.Level(), c.RealIP()))
+
+	return a.logLevel(c)
+}
+
+// reload the error is only logged, it may describe the configuration or its files
+func (a *Admin) reload(c Echo.Context) error {
+	a.mutex.RLock()
+	reloader := a.reloader
+	a.mutex.RUnlock()
+
+	if reloader == nil {
+		return a.respond(c, http.StatusNotFound, Response.GeneralError, "nothing to reload", nil)
+	}
+
+	if err := reloader(c.Request().Context()); err != nil {
+		a.logError(fmt.Sprintf("reload from %s error : %+v", c.RealIP(), err))
+		return a.respond(c, http.StatusInternalServerError, Response.GeneralError, "reload failed, see the logs", nil)
+	}
+
+	a.log(fmt.Sprintf("reloaded from %s", c.RealIP()))
+
+	return a.respond(c, http.StatusOK, Response.SuccessCode, "reloaded", nil)
+}
+
+func (a *Admin) log(message string) {
+	a.mutex.RLock()
+	logger := a.logger
+	a.mutex.RUnlock()
+
+	logger.Info(message)
+}
+
+func (a *Admin) logError(message string) {
+	a.mutex.RLock()
+	logger := a.logger
+	a.mutex.RUnlock()
+
+	logger.Error(message)
+}
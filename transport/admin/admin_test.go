@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agitdevcenter/gopkg/health"
+	Middleware "github.com/agitdevcenter/gopkg/transport/http/middleware"
+	Echo "github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(e *Echo.Echo, method, url, body string, authorize func(r *http.Request)) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, url, strings.NewReader(body))
+	request.Header.Set(Echo.HeaderContentType, Echo.MIMEApplicationJSON)
+	if authorize != nil {
+		authorize(request)
+	}
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAuthentication(t *testing.T) {
+	e := Echo.New()
+	New([]Option{WithBasicAuth("admin", "secret"), WithToken("token"), WithHealthRegistry(health.NewRegistry())}).Route(e)
+
+	assert.Equal(t, http.StatusUnauthorized, serve(e, "GET", InfoURL, "", nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(e, "GET", InfoURL, "", func(r *http.Request) { r.SetBasicAuth("admin", "wrong") }).Code)
+	assert.Equal(t, http.StatusOK, serve(e, "GET", InfoURL, "", func(r *http.Request) { r.SetBasicAuth("admin", "secret") }).Code)
+	assert.Equal(t, http.StatusOK, serve(e, "GET", InfoURL, "", func(r *http.Request) { r.Header.Set("Authorization", "Bearer token") }).Code)
+
+	// probes are not authenticated
+	assert.Equal(t, http.StatusNoContent, serve(e, "GET", LivenessURL, "", nil).Code)
+	assert.Equal(t, http.StatusNoContent, serve(e, "GET", ReadinessURL, "", nil).Code)
+}
+
+func TestEndpoints(t *testing.T) {
+	registry := health.NewRegistry()
+	assert.NoError(t, registry.Register(health.Check{Name: "mysql", Critical: true, Checker: health.CheckerFunc(func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})}))
+
+	middleware := Middleware.New([]Middleware.Option{Middleware.WithAvailability(""), Middleware.WithEndpointAvailability([]string{"/payment"})})
+
+	e := Echo.New()
+	New([]Option{WithHealthRegistry(registry), WithMiddleware(middleware), WithBuildInfo("payment", "1.2.0", map[string]string{"commit": "abc123"})}).Route(e)
+
+	assert.Contains(t, serve(e, "GET", InfoURL, "", nil).Body.String(), `"commit":"abc123"`)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(e, "GET", HealthURL, "", nil).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(e, "GET", ReadinessURL, "", nil).Code)
+
+	assert.Equal(t, http.StatusOK, serve(e, "PUT", AvailabilityURL, `{"path":"/payment","available":false}`, nil).Code)
+	assert.Equal(t, http.StatusOK, serve(e, "PUT", AvailabilityURL, `{"available":false}`, nil).Code)
+	status := middleware.Availability().Status()
+	assert.False(t, status.Server.Available)
+	assert.False(t, status.Endpoints["/payment"].Available)
+	assert.Equal(t, "admin", status.Server.UpdatedBy)
+
+	assert.Equal(t, http.StatusBadRequest, serve(e, "PUT", AvailabilityURL, `{"path":"/account","available":false}`, nil).Code)
+
+	// the noop logger has no level
+	assert.Equal(t, http.StatusNotFound, serve(e, "GET", LogLevelURL, "", nil).Code)
+}
+
+func TestReload(t *testing.T) {
+	var reloaded int
+	err := errors.New("open /etc/payment/tls.key: permission denied")
+	reloader := func(ctx context.Context) error {
+		reloaded++
+		return err
+	}
+
+	e := Echo.New()
+	New([]Option{WithToken("token"), WithReloader(reloader), WithHealthRegistry(health.NewRegistry())}).Route(e)
+	authorize := func(r *http.Request) { r.Header.Set("Authorization", "Bearer token") }
+
+	assert.Equal(t, http.StatusUnauthorized, serve(e, "POST", ReloadURL, "", nil).Code)
+	assert.Equal(t, 0, reloaded)
+
+	// the reload error is logged, not answered
+	recorder := serve(e, "POST", ReloadURL, "", authorize)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "tls.key")
+	assert.Equal(t, 1, reloaded)
+
+	err = nil
+	assert.Equal(t, http.StatusOK, serve(e, "POST", ReloadURL, "", authorize).Code)
+	assert.Equal(t, 2, reloaded)
+
+	e = Echo.New()
+	New([]Option{WithHealthRegistry(health.NewRegistry())}).Route(e)
+	assert.Equal(t, http.StatusNotFound, serve(e, "POST", ReloadURL, "", nil).Code)
+}
@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/agitdevcenter/gopkg/health"
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/agitdevcenter/gopkg/metrics"
+	HTTP "github.com/agitdevcenter/gopkg/transport/http"
+	Middleware "github.com/agitdevcenter/gopkg/transport/http/middleware"
+)
+
+type Option func(*Admin)
+
+func WithDebug(enabled bool) Option {
+	return func(a *Admin) {
+		a.debug = enabled
+		a.serverOptions = append(a.serverOptions, HTTP.WithDebug(enabled))
+	}
+}
+
+func WithLogger(logger Logger.Logger) Option {
+	return func(a *Admin) {
+		a.logger = logger
+		a.serverOptions = append(a.serverOptions, HTTP.WithLogger(logger))
+	}
+}
+
+// WithAddress host and port of the admin server, default is DefaultHost and DefaultPort
+func WithAddress(host string, port int) Option {
+	return func(a *Admin) {
+		a.host = host
+		a.port = port
+	}
+}
+
+// WithServer options of the underlying HTTP server, ex: HTTP.WithTLS or HTTP.WithUnixSocket
+func WithServer(opts ...HTTP.Option) Option {
+	return func(a *Admin) {
+		a.serverOptions = append(a.serverOptions, opts...)
+	}
+}
+
+func WithBasicAuth(username, password string) Option {
+	return func(a *Admin) {
+		a.username = username
+		a.password = password
+	}
+}
+
+// WithToken bearer token of the Authorization header
+func WithToken(token string) Option {
+	return func(a *Admin) {
+		a.token = token
+	}
+}
+
+// WithProfiling serves pprof on DebugURL
+func WithProfiling(enabled bool) Option {
+	return func(a *Admin) {
+		a.profiling = enabled
+	}
+}
+
+// WithMetrics serves the registry of the metrics on metrics.DefaultURL
+func WithMetrics(metrics *metrics.Metrics) Option {
+	return func(a *Admin) {
+		a.metrics = metrics
+	}
+}
+
+func WithHealthRegistry(registry *health.Registry) Option {
+	return func(a *Admin) {
+		if registry != nil {
+			a.healthRegistry = registry
+		}
+	}
+}
+
+// WithMiddleware middleware of the public server, its availability is served on AvailabilityURL
+func WithMiddleware(middleware *Middleware.Middleware) Option {
+	return func(a *Admin) {
+		a.middleware = middleware
+	}
+}
+
+// WithReloader called on ReloadURL, ex: the Reload of the middleware when the admin server is not run by transport
+func WithReloader(reloader func(ctx context.Context) error) Option {
+	return func(a *Admin) {
+		a.reloader = reloader
+	}
+}
+
+// WithBuildInfo name, version and extra values served on InfoURL, ex: the commit set with -ldflags
+func WithBuildInfo(name, version string, extra map[string]string) Option {
+	return func(a *Admin) {
+		a.name = name
+		a.version = version
+		a.buildInfo = extra
+	}
+}
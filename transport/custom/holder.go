@@ -3,8 +3,11 @@ package custom
 import "time"
 
 type Holder struct {
-	hold    time.Duration
-	service Service
+	hold         time.Duration
+	service      Service
+	name         string
+	dependsOn    []string
+	startTimeout time.Duration
 }
 
 func New(opts ...Option) *Holder {
@@ -26,3 +29,18 @@ func (h *Holder) Hold() {
 		time.Sleep(h.hold)
 	}
 }
+
+// Name used by other services and servers to depend on this service
+func (h *Holder) Name() string {
+	return h.name
+}
+
+// DependsOn names of the services and servers started before and stopped after this service
+func (h *Holder) DependsOn() []string {
+	return h.dependsOn
+}
+
+// StartTimeout maximum wait for the service to be ready, see Readiness
+func (h *Holder) StartTimeout() time.Duration {
+	return h.startTimeout
+}
@@ -14,3 +14,21 @@ func OptionHold(hold time.Duration) Option {
 		h.hold = hold
 	}
 }
+
+func OptionName(name string) Option {
+	return func(h *Holder) {
+		h.name = name
+	}
+}
+
+func OptionDependsOn(names ...string) Option {
+	return func(h *Holder) {
+		h.dependsOn = append(h.dependsOn, names...)
+	}
+}
+
+func OptionStartTimeout(startTimeout time.Duration) Option {
+	return func(h *Holder) {
+		h.startTimeout = startTimeout
+	}
+}
@@ -11,3 +11,15 @@ type Service interface {
 	SetLogger(logger Logger.Logger)
 	Start(ctx context.Context, wg *sync.WaitGroup) func() error
 }
+
+// Readiness optionally implemented by services and servers, dependents start once Ready is closed.
+// Services without it are ready as soon as they are started.
+type Readiness interface {
+	Ready() <-chan struct{}
+}
+
+// Reloadable optionally implemented by services, servers and the logger, Reload is called on SIGHUP
+// to apply new settings without stopping.
+type Reloadable interface {
+	Reload(ctx context.Context) error
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"time"
+
 	Logger "github.com/agitdevcenter/gopkg/logger"
+	Session "github.com/agitdevcenter/gopkg/session"
 	"github.com/agitdevcenter/gopkg/transport"
 	"github.com/agitdevcenter/gopkg/transport/custom"
-	"github.com/agitdevcenter/gopkg/transport/example/kafka/service"
-	"time"
+	"github.com/agitdevcenter/gopkg/transport/kafka"
+	Kafka "github.com/segmentio/kafka-go"
 )
 
 func main() {
@@ -17,8 +21,18 @@ func main() {
 		Stdout:          true,
 	})
 
-	k := service.New()
-	holder := custom.New(custom.OptionService(k))
+	consumer := kafka.NewConsumer([]kafka.Option{
+		kafka.WithBrokers(":9092"),
+		kafka.WithGroup("reminder-balancing"),
+		kafka.WithTopics("reminder"),
+		kafka.WithDeadLetter("reminder-dlq"),
+		kafka.WithConcurrency(4),
+		kafka.WithHandler(kafka.HandlerFunc(func(ctx context.Context, session *Session.Session, message Kafka.Message) error {
+			session.Info(fmt.Sprintf("partition %d offset %d : %s", message.Partition, message.Offset, message.Value))
+			return nil
+		})),
+	})
+	holder := custom.New(custom.OptionService(consumer), custom.OptionName("consumer"))
 
 	t := transport.New([]transport.Option{
 		transport.WithDebug(true),
@@ -1,19 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	Logger "github.com/agitdevcenter/gopkg/logger"
+	Session "github.com/agitdevcenter/gopkg/session"
 	"github.com/agitdevcenter/gopkg/transport"
 	"github.com/agitdevcenter/gopkg/transport/custom"
 	Handler "github.com/agitdevcenter/gopkg/transport/example/grpc/handler"
 	HTTPHandler "github.com/agitdevcenter/gopkg/transport/example/http/handler"
 	HTTPRouter "github.com/agitdevcenter/gopkg/transport/example/http/router"
-	"github.com/agitdevcenter/gopkg/transport/example/kafka/service"
-	"github.com/agitdevcenter/gopkg/transport/example/multi/ulang"
 	"github.com/agitdevcenter/gopkg/transport/grpc"
 	Interceptor "github.com/agitdevcenter/gopkg/transport/grpc/interceptor"
 	"github.com/agitdevcenter/gopkg/transport/http"
 	HTTPMiddleware "github.com/agitdevcenter/gopkg/transport/http/middleware"
+	"github.com/agitdevcenter/gopkg/transport/kafka"
+	"github.com/agitdevcenter/gopkg/transport/scheduler"
+	Kafka "github.com/segmentio/kafka-go"
 	"time"
 )
 
@@ -53,12 +56,29 @@ func main() {
 	})
 
 	// kafka service
-	k := service.New()
+	k := kafka.NewConsumer([]kafka.Option{
+		kafka.WithBrokers(":9092"),
+		kafka.WithGroup("reminder-balancing"),
+		kafka.WithTopics("reminder"),
+		kafka.WithHandler(kafka.HandlerFunc(func(ctx context.Context, session *Session.Session, message Kafka.Message) error {
+			return nil
+		})),
+	})
 	kafkaHolder := custom.New(custom.OptionService(k))
 
-	// ulang service
-	u := ulang.New()
-	ulangHolder := custom.New(custom.OptionService(u))
+	// scheduler service
+	s := scheduler.New([]scheduler.Option{
+		scheduler.WithJobs(scheduler.Job{
+			Name:     "reminder",
+			Schedule: scheduler.MustCron("*/5 * * * *"),
+			Timeout:  time.Minute,
+			Run: func(ctx context.Context, session *Session.Session) error {
+				session.Info("sending reminders")
+				return nil
+			},
+		}),
+	})
+	schedulerHolder := custom.New(custom.OptionService(s))
 
 	// transport
 	t := transport.New([]transport.Option{
@@ -68,7 +88,7 @@ func main() {
 		transport.WithHTTPServer(httpServer),
 		transport.WithGRPCServer(g),
 		transport.WithCustom(kafkaHolder),
-		transport.WithCustom(ulangHolder),
+		transport.WithCustom(schedulerHolder),
 	})
 
 	if err := t.Run(); err != nil {
@@ -3,16 +3,20 @@ package interceptor
 import (
 	"context"
 	"fmt"
+	Error "github.com/agitdevcenter/gopkg/error"
 	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/agitdevcenter/gopkg/metrics"
+	Response "github.com/agitdevcenter/gopkg/response"
 	Session "github.com/agitdevcenter/gopkg/session"
 	"github.com/agitdevcenter/gopkg/utils"
-	ValueObject "github.com/agitdevcenter/gopkg/vo"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -49,6 +53,9 @@ type Interceptor struct {
 	handleCrash                bool
 	skipRPCs                   []string
 	internalServerErrorMessage string
+	reload                     func() ([]Option, error)
+	metrics                    *metrics.Metrics
+	mutex                      sync.RWMutex
 }
 
 func New(opts []Option) *Interceptor {
@@ -84,8 +91,32 @@ func (i *Interceptor) SetPort(port int) {
 	i.port = port
 }
 
+// Reload applies the skip list of the options returned by WithReload
+func (i *Interceptor) Reload(ctx context.Context) error {
+	if i.reload == nil {
+		return nil
+	}
+
+	opts, err := i.reload()
+	if err != nil {
+		return fmt.Errorf("reloading interceptor options : %+v", err)
+	}
+
+	reloaded := New(opts)
+
+	i.mutex.Lock()
+	i.skipRPCs = reloaded.skipRPCs
+	i.mutex.Unlock()
+
+	return nil
+}
+
 func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		if i.metrics != nil {
+			defer i.observe(info.FullMethod, "stream", time.Now(), &err)
+		}
+
 		var session *Session.Session
 		if i.session {
 			session = Session.New(i.logger).
@@ -104,20 +135,24 @@ func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
 			err = i.panicError(r, session)
 		})
 
-		var ctx context.Context
+		ctx := stream.Context()
 
-		if !i.skip(info.FullMethod) {
-			ctx = context.WithValue(ctx, ValueObject.AppSession, session)
+		if !i.skip(info.FullMethod) && session != nil {
+			ctx = Session.NewContext(ctx, session)
 		}
 
 		wrapped := WrapServerStream(stream)
 		wrapped.WrappedContext = ctx
-		return handler(srv, wrapped)
+		return i.renderError(handler(srv, wrapped), session)
 	}
 }
 
 func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (response interface{}, err error) {
+		if i.metrics != nil {
+			defer i.observe(info.FullMethod, "unary", time.Now(), &err)
+		}
+
 		var session *Session.Session
 		if i.session {
 			session = Session.New(i.logger).
@@ -136,7 +171,9 @@ func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
 			if i.session && session != nil {
 				session.T1("Incoming Request")
 			}
-			ctx = context.WithValue(ctx, ValueObject.AppSession, session)
+			if session != nil {
+				ctx = Session.NewContext(ctx, session)
+			}
 		}
 
 		if i.handleCrash {
@@ -146,6 +183,7 @@ func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
 		}
 
 		response, err = handler(ctx, req)
+		err = i.renderError(err, session)
 
 		if i.session && session != nil {
 			session.T4(response)
@@ -155,7 +193,27 @@ func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
 	}
 }
 
+// observe the call in the metrics once err is final, the business code is the one of the application error
+func (i *Interceptor) observe(method, kind string, start time.Time, err *error) {
+	if i.skip(method) {
+		return
+	}
+
+	var code string
+	if he, ok := Error.As(Error.FromStatus(*err)); ok {
+		code = he.ErrorCode
+	}
+
+	grpcCode := status.Code(*err)
+	failed := grpcCode != codes.OK || len(code) > 0 && code != Response.SuccessCode
+
+	i.metrics.Observe(method, kind, grpcCode.String(), code, failed, time.Since(start))
+}
+
 func (i *Interceptor) skip(method string) (skip bool) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
 	for _, url := range i.skipRPCs {
 		if strings.HasPrefix(strings.ToLower(method), url) {
 			skip = true
@@ -177,6 +235,12 @@ func getXID(ctx context.Context) string {
 				return xid[0]
 			}
 		}
+		// metadata keys are lower cased on the wire, grpc/client sends RequestID
+		for _, key := range []string{"x-request-id", "requestid"} {
+			if xid := md.Get(key); len(xid) > 0 {
+				return xid[0]
+			}
+		}
 	}
 	return utils.GenerateThreadId()
 }
@@ -226,6 +290,26 @@ func handleCrash(handler func(interface{})) {
 	}
 }
 
+// renderError answers application errors anywhere in the chain of err with their gRPC code
+func (i *Interceptor) renderError(err error, session *Session.Session) error {
+	if err == nil {
+		return nil
+	}
+
+	if session != nil {
+		session.SetErrorMessage(err.Error())
+	}
+
+	if he, ok := Error.As(err); ok {
+		if session != nil {
+			session.SetResponseCode(he.ErrorCode)
+		}
+		return he.GRPCStatus().Err()
+	}
+
+	return err
+}
+
 func (i *Interceptor) panicError(r interface{}, session *Session.Session) error {
 	message := fmt.Sprintf("gRPC error : %+v", r)
 	if i.session && session != nil {
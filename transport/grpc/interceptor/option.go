@@ -1,6 +1,9 @@
 package interceptor
 
-import Logger "github.com/agitdevcenter/gopkg/logger"
+import (
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/agitdevcenter/gopkg/metrics"
+)
 
 type Option func(*Interceptor)
 
@@ -42,3 +45,17 @@ func WithSkip(urls []string) Option {
 		i.skipRPCs = urls
 	}
 }
+
+// WithReload load returns the whole option list given to New, Reload applies its skip list
+func WithReload(load func() ([]Option, error)) Option {
+	return func(i *Interceptor) {
+		i.reload = load
+	}
+}
+
+// WithMetrics counts the calls and their latency by full method, ex: metrics.New(metrics.SubsystemGRPC, nil)
+func WithMetrics(metrics *metrics.Metrics) Option {
+	return func(i *Interceptor) {
+		i.metrics = metrics
+	}
+}
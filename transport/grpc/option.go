@@ -1,10 +1,12 @@
 package grpc
 
 import (
+	"github.com/agitdevcenter/gopkg/health"
 	Logger "github.com/agitdevcenter/gopkg/logger"
 	Handler "github.com/agitdevcenter/gopkg/transport/grpc/handler"
 	"github.com/agitdevcenter/gopkg/transport/grpc/interceptor"
 	"google.golang.org/grpc/keepalive"
+	"net"
 )
 
 type Option func(*Server)
@@ -99,3 +101,24 @@ func WithTracing(tracing bool, tracingName string) Option {
 		s.tracingName = tracingName
 	}
 }
+
+// WithListener serves on listener instead of listening on the address
+func WithListener(listener net.Listener) Option {
+	return func(s *Server) {
+		s.listener = listener
+	}
+}
+
+// WithUnixSocket listens on the unix domain socket at path instead of host and port
+func WithUnixSocket(path string) Option {
+	return func(s *Server) {
+		s.socket = path
+	}
+}
+
+// WithHealthRegistry checks answered by the gRPC health service instead of health.DefaultRegistry
+func WithHealthRegistry(registry *health.Registry) Option {
+	return func(s *Server) {
+		s.healthRegistry = registry
+	}
+}
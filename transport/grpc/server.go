@@ -5,7 +5,9 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"github.com/agitdevcenter/gopkg/crypto/certificate"
 	"github.com/agitdevcenter/gopkg/grpc/health"
+	Health "github.com/agitdevcenter/gopkg/health"
 	Logger "github.com/agitdevcenter/gopkg/logger"
 	Handler "github.com/agitdevcenter/gopkg/transport/grpc/handler"
 	Interceptor "github.com/agitdevcenter/gopkg/transport/grpc/interceptor"
@@ -19,6 +21,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net"
+	"os"
 	"strings"
 	"sync"
 )
@@ -43,12 +46,21 @@ type Server struct {
 	interceptor               *Interceptor.Interceptor
 	tracing                   bool
 	tracingName               string
+	mutex                     sync.RWMutex
+	keypair                   *certificate.Keypair
+	healthRegistry            *Health.Registry
+	listener                  net.Listener
+	socket                    string
+	addr                      net.Addr
+	ready                     chan struct{}
+	readyOnce                 sync.Once
 }
 
 func New(opts []Option) *Server {
 	s := &Server{
 		port:    2202,
 		inherit: true,
+		ready:   make(chan struct{}),
 	}
 
 	for _, opt := range opts {
@@ -70,10 +82,99 @@ func (s *Server) SetDebug(enabled bool) {
 	s.debug = enabled
 }
 
+// SetHealthRegistry checks answered by the gRPC health service, see WithHealthRegistry
+func (s *Server) SetHealthRegistry(registry *Health.Registry) {
+	s.healthRegistry = registry
+}
+
+// SetListener serves on listener instead of listening on the address, ex: a listener inherited from the parent process
+func (s *Server) SetListener(listener net.Listener) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listener = listener
+}
+
+// Listener the listener being served, nil before the server is started
+func (s *Server) Listener() net.Listener {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.listener
+}
+
+func (s *Server) listen() (net.Listener, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener == nil {
+		network := "tcp"
+		if len(s.socket) > 0 {
+			network = "unix"
+			// socket file left by a process that did not stop cleanly
+			if info, err := os.Stat(s.socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+				os.Remove(s.socket)
+			}
+		}
+
+		listener, err := net.Listen(network, s.Address())
+		if err != nil {
+			return nil, err
+		}
+		s.listener = listener
+	}
+
+	s.addr = s.listener.Addr()
+	s.readyOnce.Do(func() {
+		close(s.ready)
+	})
+
+	return s.listener, nil
+}
+
+// Address configured address, host:port or the unix socket path, see Addr for the bound address
 func (s *Server) Address() string {
+	if len(s.socket) > 0 {
+		return s.socket
+	}
 	return fmt.Sprintf("%s:%d", s.host, s.port)
 }
 
+// Addr address the server is bound to, ex: the port chosen when port is 0, nil before the server is listening
+func (s *Server) Addr() net.Addr {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.addr
+}
+
+// Ready closed once the server is listening, see custom.Readiness
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Reload reads the TLS certificate files again and reloads the interceptor, connections are kept
+func (s *Server) Reload(ctx context.Context) error {
+	s.mutex.RLock()
+	keypair := s.keypair
+	s.mutex.RUnlock()
+
+	if keypair != nil {
+		if err := keypair.Reload(); err != nil {
+			return fmt.Errorf("reloading grpc server certificate : %+v", err)
+		}
+	}
+
+	if s.interceptor != nil {
+		if err := s.interceptor.Reload(ctx); err != nil {
+			return err
+		}
+	}
+
+	if s.debug {
+		s.logger.Info(fmt.Sprintf("grpc server on %s reloaded", s.Address()))
+	}
+
+	return nil
+}
+
 func (s *Server) inheritInterceptor() {
 	if s.inherit && s.interceptor != nil {
 		s.interceptor.SetDebug(s.debug)
@@ -103,23 +204,21 @@ func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
 			return fmt.Errorf("please specify grpc service method (unary &/ stream)")
 		}
 
-		var listener net.Listener
-		var err error
-		if listener, err = net.Listen("tcp", s.Address()); err != nil {
-			return fmt.Errorf("%s already in use, error : %+v", s.Address(), err)
-		}
-
 		// initialize grpc options
 		var options []grpc.ServerOption
 
 		if s.tls {
-			certificate, err := tls.LoadX509KeyPair(s.certificateFile, s.keyFile)
+			keypair, err := certificate.NewKeypair(s.certificateFile, s.keyFile)
 			if err != nil {
-				return fmt.Errorf("could not load grpc certificates : %+v", certificate)
+				return fmt.Errorf("could not load grpc certificates : %+v", err)
 			}
+			s.mutex.Lock()
+			s.keypair = keypair
+			s.mutex.Unlock()
 
+			// the certificate is read through the keypair so Reload can replace it
 			tlsConfig := &tls.Config{
-				Certificates: []tls.Certificate{certificate},
+				GetCertificate: keypair.GetCertificate,
 			}
 
 			if len(s.rootCAFile) > 0 {
@@ -169,9 +268,14 @@ func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
 			}
 		}
 
+		listener, err := s.listen()
+		if err != nil {
+			return fmt.Errorf("%s already in use, error : %+v", s.Address(), err)
+		}
+
 		server := grpc.NewServer(options...)
 
-		health.RegisterHealthServer(server)
+		health.RegisterHealthServer(server, health.WithRegistry(s.healthRegistry))
 
 		if s.handler != nil {
 			s.handler.Register(server)
@@ -192,10 +296,15 @@ func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
 		}()
 
 		if s.debug {
-			s.logger.Info(fmt.Sprintf("starting grpc server on %s", s.Address()))
+			s.logger.Info(fmt.Sprintf("starting grpc server on %s", listener.Addr()))
 		}
 
-		if err := server.Serve(listener); err != nil {
+		err = server.Serve(listener)
+
+		// the listener is closed with the server
+		s.SetListener(nil)
+
+		if err != nil {
 			return fmt.Errorf("error starting grpc server : %+v", err)
 		}
 
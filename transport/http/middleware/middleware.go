@@ -2,21 +2,26 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"github.com/agitdevcenter/gopkg/availability"
 	Error "github.com/agitdevcenter/gopkg/error"
+	"github.com/agitdevcenter/gopkg/health"
 	"github.com/agitdevcenter/gopkg/json"
 	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/agitdevcenter/gopkg/metrics"
 	Response "github.com/agitdevcenter/gopkg/response"
 	Session "github.com/agitdevcenter/gopkg/session"
 	Utils "github.com/agitdevcenter/gopkg/utils"
 	ValueObject "github.com/agitdevcenter/gopkg/vo"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	"gopkg.in/go-playground/validator.v9"
 	"io/ioutil"
 	"net/http"
 	_ "net/http/pprof"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,10 +32,24 @@ const (
 	RequestTime                = "RequestTime"
 	RequestID                  = "RequestID"
 	RequestError               = "RequestError"
-	AlreadyLogged              = "AlreadyLogged"
+	AlreadyLogged              = ValueObject.AlreadyLogged
+	ResponseCode               = ValueObject.AppResponseCode
 	DebugURL                   = "/debug/pprof/*"
+	// UnmatchedRoute route label of the metrics of requests matching no route
+	UnmatchedRoute = "unmatched"
+	// OtherMethod method label of the metrics of requests with a non standard method
+	OtherMethod = "OTHER"
 )
 
+// DefaultCORSConfig used by WithCORS
+var DefaultCORSConfig = middleware.CORSConfig{
+	AllowOrigins:     []string{"*"},
+	AllowMethods:     []string{echo.GET, echo.POST, echo.OPTIONS},
+	AllowHeaders:     []string{"Origin", "Authorization", "Access-Control-Allow-Origin", "token", "Pv", echo.HeaderContentType, "Accept", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization"},
+	ExposeHeaders:    []string{"Content-Length", "Access-Control-Allow-Origin"},
+	AllowCredentials: true,
+}
+
 type Middleware struct {
 	logger                     Logger.Logger
 	debug                      bool
@@ -48,22 +67,51 @@ type Middleware struct {
 	skipURLs                   []string
 	health                     bool
 	healthURL                  string
+	healthRegistry             *health.Registry
+	livenessURL                string
+	readinessURL               string
+	metrics                    *metrics.Metrics
+	metricsURL                 string
 	profiling                  bool
-	available                  bool
 	availabilityEnabled        bool
 	availabilityURLPrefix      string
 	endpointAvailabilityURLs   []string
-	endpointAvailabilityMap    map[string]bool
+	availability               *availability.Availability
+	availabilityOptions        []availability.Option
+	availabilityAuth           availability.Authenticator
+	baggageKeys                []string
+	responder                  *ValueObject.Responder
+	dataValidator              *DataValidator
+	corsConfig                 middleware.CORSConfig
+	corsMiddleware             echo.MiddlewareFunc
+	reload                     func() ([]Option, error)
+	// guards the settings applied by Reload and the availability toggles
+	mutex sync.RWMutex
 }
 
 func New(opts []Option) *Middleware {
+	m := configure(opts)
+
+	m.corsMiddleware = middleware.CORSWithConfig(m.corsConfig)
+
+	if m.availabilityEnabled {
+		m.availability = m.newAvailability()
+	}
+
+	return m
+}
+
+// configure applies opts on the defaults, without creating the CORS middleware and the availability
+// so Reload can read the options again without side effects
+func configure(opts []Option) *Middleware {
 	m := &Middleware{
 		port:                       80,
 		name:                       Name,
 		version:                    Version,
 		internalServerErrorMessage: InternalServerErrorMessage,
-		available:                  true,
-		endpointAvailabilityMap:    make(map[string]bool),
+		responder:                  ValueObject.DefaultResponder,
+		corsConfig:                 DefaultCORSConfig,
+		healthRegistry:             health.DefaultRegistry,
 	}
 
 	for _, opt := range opts {
@@ -78,31 +126,89 @@ func New(opts []Option) *Middleware {
 		m.skipURLs = append(m.skipURLs, DebugURL[0:len(DebugURL)-2])
 	}
 
-	if m.availabilityEnabled {
-		m.skipURLs = append(m.skipURLs, m.availabilityURLPrefix)
+	if m.metrics != nil && len(m.metricsURL) > 0 {
+		m.skipURLs = append(m.skipURLs, m.metricsURL)
+	}
 
-		if len(m.endpointAvailabilityURLs) > 0 {
-			for _, path := range m.endpointAvailabilityURLs {
-				m.endpointAvailabilityMap[path] = true
-			}
-		}
+	if m.availabilityEnabled && len(m.availabilityURLPrefix) > 0 {
+		m.skipURLs = append(m.skipURLs, m.availabilityURLPrefix)
 	}
 
 	return m
 }
 
+func (m *Middleware) newAvailability() *availability.Availability {
+	return availability.New(append([]availability.Option{
+		availability.WithLogger(m.logger),
+		availability.WithEndpoints(m.endpointAvailabilityURLs),
+	}, m.availabilityOptions...))
+}
+
 func (m *Middleware) SetPort(port int) {
 	m.port = port
 }
 
 func (m *Middleware) SetLogger(logger Logger.Logger) {
 	m.logger = logger
+	if m.availability != nil {
+		m.availability.SetLogger(logger)
+	}
 }
 
 func (m *Middleware) SetDebug(enabled bool) {
 	m.debug = enabled
 }
 
+// SetHealthRegistry checks served on the health and readiness URL, see WithHealthRegistry
+func (m *Middleware) SetHealthRegistry(registry *health.Registry) {
+	if registry != nil {
+		m.healthRegistry = registry
+	}
+}
+
+// Reload applies the skip list, CORS and availability settings of the options returned by WithReload,
+// toggled availability states are kept. The availability is created once: WithAvailabilityOptions, such as
+// its backend, are read again only when the availability is enabled by this reload, changing them needs a restart
+func (m *Middleware) Reload(ctx context.Context) error {
+	if m.reload == nil {
+		return nil
+	}
+
+	opts, err := m.reload()
+	if err != nil {
+		return fmt.Errorf("reloading middleware options : %+v", err)
+	}
+
+	reloaded := configure(opts)
+	corsMiddleware := middleware.CORSWithConfig(reloaded.corsConfig)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.skipURLs = reloaded.skipURLs
+	m.cors = reloaded.cors
+	m.corsConfig = reloaded.corsConfig
+	m.corsMiddleware = corsMiddleware
+	m.availabilityEnabled = reloaded.availabilityEnabled
+	m.availabilityURLPrefix = reloaded.availabilityURLPrefix
+	m.endpointAvailabilityURLs = reloaded.endpointAvailabilityURLs
+	m.availabilityAuth = reloaded.availabilityAuth
+	if m.availability == nil {
+		if m.availabilityEnabled {
+			m.availabilityOptions = reloaded.availabilityOptions
+			m.availability = m.newAvailability()
+		}
+	} else {
+		m.availability.SetEndpoints(reloaded.endpointAvailabilityURLs)
+	}
+
+	if m.debug {
+		m.logger.Info(fmt.Sprintf("middleware of HTTP server [%s %s] at [:%d] reloaded", m.name, m.version, m.port))
+	}
+
+	return nil
+}
+
 func (m *Middleware) Setup(e *echo.Echo) {
 
 	if m.profiling {
@@ -111,12 +217,47 @@ func (m *Middleware) Setup(e *echo.Echo) {
 
 	if m.health {
 		e.GET(m.healthURL, func(c echo.Context) error {
-			return m.healthHandler(c)
+			return m.healthHandler(c, m.healthRegistry.Health)
 		})
 	}
 
+	if len(m.livenessURL) > 0 {
+		e.GET(m.livenessURL, func(c echo.Context) error {
+			return m.livenessHandler(c)
+		})
+	}
+
+	if len(m.readinessURL) > 0 {
+		e.GET(m.readinessURL, func(c echo.Context) error {
+			return m.healthHandler(c, m.healthRegistry.Check)
+		})
+	}
+
+	if m.metrics != nil && len(m.metricsURL) > 0 {
+		e.GET(m.metricsURL, echo.WrapHandler(m.metrics.Handler()))
+	}
+
 	e.Pre(middleware.RemoveTrailingSlash())
 
+	if m.metrics != nil {
+		// outermost so requests answered by the other middleware are counted with their final status
+		e.Use(func(h echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				start := time.Now()
+				// the error is answered here, when body dumping did not already, so the final status is observed,
+				// it is not returned so echo does not handle it again
+				if err := h(c); err != nil && !c.Response().Committed {
+					c.Error(err)
+				}
+
+				if !m.skip(c) {
+					m.observe(c, time.Since(start))
+				}
+				return nil
+			}
+		})
+	}
+
 	e.Use(func(h echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 
@@ -128,101 +269,12 @@ func (m *Middleware) Setup(e *echo.Echo) {
 			}
 
 			c.Set(RequestID, reqId)
+			c.Set(ValueObject.AppResponder, m.responder)
 
-			// check if server availability toggle is enabled
-			if m.availabilityEnabled {
-				// toggle availability for all server endpoint
-				if c.Path() == m.availabilityURLPrefix {
-					// negate the status
-					m.available = !m.available
-
-					if m.debug {
-						m.logger.Info(fmt.Sprintf("availability status for HTTP server [%s %s] at [:%d] is %v", m.name, m.version, m.port, m.available))
-					}
-
-					response := make(map[string]bool)
-					response["available"] = m.available
-
-					return c.JSON(200, Response.DefaultResponse{
-						Data: response,
-						Response: Response.Response{
-							Status:  Response.SuccessCode,
-							Message: http.StatusText(http.StatusOK),
-						},
-					})
-				}
-
-				// check if we have configurations for specific url
-				if len(m.endpointAvailabilityURLs) > 0 {
-					// no need to check if the whole server is unavailable
-					if m.available {
-						// check only for url not in skipped list
-						if !m.skip(c) {
-							// go through the url list
-							for _, path := range m.endpointAvailabilityURLs {
-								// we go by prefix here
-								if strings.HasPrefix(c.Path(), path) {
-									// check if url is available
-									var available bool
-									var ok bool
-									if available, ok = m.endpointAvailabilityMap[path]; !ok {
-										available = false
-									}
-									// if not available return error
-									if !available {
-										return c.JSON(http.StatusServiceUnavailable, Response.DefaultResponse{
-											Response: Response.Response{
-												Status:  Response.GeneralError,
-												Message: http.StatusText(http.StatusServiceUnavailable),
-											},
-										})
-									}
-								}
-							}
-						}
-					}
-
-					// toggle by url
-					for _, path := range m.endpointAvailabilityURLs {
-						// combine url toggle prefix with endpoint url
-						if strings.HasPrefix(c.Path(), m.availabilityURLPrefix+path) {
-							// check if available
-							var available bool
-							var ok bool
-							if available, ok = m.endpointAvailabilityMap[path]; !ok {
-								available = false
-							}
-							// negate the status
-							m.endpointAvailabilityMap[path] = !available
-
-							if m.debug {
-								m.logger.Info(fmt.Sprintf("availability status for endpoint [%s] on HTTP server [%s %s] at [:%d] is %v", path, m.name, m.version, m.port, m.endpointAvailabilityMap[path]))
-							}
-
-							response := make(map[string]interface{})
-							response["path"] = path
-							response["available"] = m.endpointAvailabilityMap[path]
-
-							return c.JSON(200, Response.DefaultResponse{
-								Data: response,
-								Response: Response.Response{
-									Status:  Response.SuccessCode,
-									Message: http.StatusText(http.StatusOK),
-								},
-							})
-						}
-					}
-				}
-			}
+			skip := m.skip(c)
 
-			// check if availability toggle is active and if it is unavailable and not in the skip list
-			if m.availabilityEnabled && !m.available && !m.skip(c) {
-				return c.JSON(http.StatusServiceUnavailable, Response.DefaultResponse{
-					Response: Response.Response{
-						Status:  Response.GeneralError,
-						Message: http.StatusText(http.StatusServiceUnavailable),
-					},
-				})
+			if handled, err := m.checkAvailability(c, skip); handled {
+				return err
 			}
 
 			if m.session {
@@ -244,17 +296,24 @@ func (m *Middleware) Setup(e *echo.Echo) {
 					SetRequest(string(request)).
 					SetHeader(formatHeader(c))
 
-				if !m.skip(c) {
+				for _, key := range m.baggageKeys {
+					if value := c.Request().Header.Get(key); len(value) > 0 {
+						session.SetBaggage(key, value)
+					}
+				}
+
+				if !skip {
 					session.T1("Incoming Request")
 				}
 
-				c.Set(ValueObject.AppSession, *session)
+				c.Set(ValueObject.AppSession, session)
+				c.SetRequest(c.Request().WithContext(Session.NewContext(c.Request().Context(), session)))
 			}
 
 			c.Response().Header().Set(echo.HeaderXRequestID, reqId)
 
 			if m.acceptJSON {
-				if !m.skip(c) {
+				if !skip {
 					if c.Request().Header.Get(echo.HeaderContentType) != echo.MIMEApplicationJSON {
 						return echo.NewHTTPError(http.StatusUnsupportedMediaType, http.StatusText(http.StatusUnsupportedMediaType))
 					}
@@ -273,20 +332,40 @@ func (m *Middleware) Setup(e *echo.Echo) {
 		e.Use(middleware.Recover())
 	}
 
-	if m.cors {
-		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-			AllowOrigins:     []string{"*"},
-			AllowMethods:     []string{echo.GET, echo.POST, echo.OPTIONS},
-			AllowHeaders:     []string{"Origin", "Authorization", "Access-Control-Allow-Origin", "token", "Pv", echo.HeaderContentType, "Accept", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization"},
-			ExposeHeaders:    []string{"Content-Length", "Access-Control-Allow-Origin"},
-			AllowCredentials: true,
-		}))
-	}
+	// CORS is looked up on every request so Reload can enable, disable or change it
+	e.Use(func(h echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			m.mutex.RLock()
+			cors, corsMiddleware := m.cors, m.corsMiddleware
+			m.mutex.RUnlock()
+
+			if cors {
+				return corsMiddleware(h)(c)
+			}
+			return h(c)
+		}
+	})
 
 	if m.validator {
-		e.Validator = &DataValidator{ValidatorData: validator.New()}
+		if m.dataValidator == nil {
+			// serving requests without the enabled validation would accept invalid payloads
+			dataValidator, err := NewDataValidator()
+			if err != nil {
+				panic(fmt.Errorf("error creating data validator : %+v", err))
+			}
+			m.dataValidator = dataValidator
+		}
+		e.Validator = m.dataValidator
 	}
 
+	// streams of ApplicationContext write to this writer so their responses are not buffered by body dumping
+	e.Use(func(h echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(ValueObject.AppResponseWriter, c.Response().Writer)
+			return h(c)
+		}
+	})
+
 	e.Use(middleware.BodyDump(func(c echo.Context, request []byte, response []byte) {
 		var alreadyLogged bool
 		var ok bool
@@ -305,16 +384,95 @@ func (m *Middleware) Setup(e *echo.Echo) {
 
 }
 
+// Availability state of the availability URL, nil when WithAvailability is not set
+func (m *Middleware) Availability() *availability.Availability {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.availability
+}
+
+// observe the request in the metrics, the business code is the one rendered by the application context,
+// the error handler or recorded in the session
+func (m *Middleware) observe(c echo.Context, duration time.Duration) {
+	code, _ := c.Get(ResponseCode).(string)
+	if len(code) == 0 {
+		if session, ok := c.Get(ValueObject.AppSession).(*Session.Session); ok {
+			code = session.ResponseCode
+		}
+	}
+
+	status := c.Response().Status
+	failed := status >= http.StatusBadRequest || len(code) > 0 && code != Response.SuccessCode
+
+	// the path of requests matching no route is the raw URL path, it would make a label value per URL
+	route := c.Path()
+	if status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
+		route = UnmatchedRoute
+		for _, registered := range c.Echo().Routes() {
+			if registered.Path == c.Path() {
+				route = c.Path()
+				break
+			}
+		}
+	}
+
+	m.metrics.Observe(route, method(c.Request().Method), strconv.Itoa(status), code, failed, duration)
+}
+
+// method label value, any method is routed by echo so non standard ones share OtherMethod
+func method(name string) string {
+	switch name {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return name
+	}
+	return OtherMethod
+}
+
+// checkAvailability answers the availability URL and the requests to unavailable paths, handled is false for other requests
+func (m *Middleware) checkAvailability(c echo.Context, skip bool) (handled bool, err error) {
+	m.mutex.RLock()
+	enabled, url, availability, authenticate := m.availabilityEnabled, m.availabilityURLPrefix, m.availability, m.availabilityAuth
+	m.mutex.RUnlock()
+
+	if !enabled {
+		return false, nil
+	}
+
+	// without URL the availability is changed by the admin server only
+	if len(url) > 0 && c.Path() == url {
+		availability.Handler(authenticate).ServeHTTP(c.Response(), c.Request())
+		return true, nil
+	}
+
+	if skip {
+		return false, nil
+	}
+
+	if available, message := availability.Check(c.Path()); !available {
+		return true, c.JSON(http.StatusServiceUnavailable, Response.DefaultResponse{
+			Response: Response.Response{
+				Status:  Response.GeneralError,
+				Message: message,
+			},
+		})
+	}
+
+	return false, nil
+}
+
 func (m *Middleware) logRequest(c echo.Context, request []byte, response []byte) {
 	if m.health && strings.HasPrefix(c.Path(), m.healthURL) || m.skip(c) {
 		return
 	}
 
 	if m.session {
-		session := c.Get(ValueObject.AppSession).(Session.Session)
+		session, ok := c.Get(ValueObject.AppSession).(*Session.Session)
+		if !ok {
+			return
+		}
 
 		var requestError error
-		var ok bool
 		if requestError, ok = c.Get(RequestError).(error); ok {
 			session.SetErrorMessage(requestError.Error())
 		}
@@ -376,22 +534,42 @@ func (m *Middleware) httpErrorHandler(err error, c echo.Context) {
 		Data: struct{}{},
 	}
 
-	if he, ok := err.(*Error.ApplicationError); ok {
+	// errors answer the HTTP status of the responder policy like ApplicationContext.Error, echo errors of the
+	// router and middlewares keep their own code, the message of application errors registered as client
+	// errors describes the request so it is answered, other errors keep the internal server error message
+	var expose bool
+	if he, ok := Error.As(err); ok {
 		response.Status = he.ErrorCode
 		response.Message = he.Message
+		if he.Details != nil {
+			response.Data = he.Details
+		}
+		code = m.responder.HTTPStatus(response.Status, err)
+		httpStatus := Error.HTTPStatus(he)
+		expose = httpStatus > 0 && httpStatus < http.StatusInternalServerError
 	} else if he, ok := err.(*echo.HTTPError); ok {
 		code = he.Code
-		response.Message = he.Message.(string)
+		if message, ok := he.Message.(string); ok {
+			response.Message = message
+		} else {
+			response.Message = fmt.Sprint(he.Message)
+		}
 	} else {
+		code = m.responder.HTTPStatus(response.Status, err)
 		response.Message = err.Error()
 	}
 
 	if !c.Response().Committed {
+		c.Set(ResponseCode, response.Status)
+
 		var responseError error
 		if c.Request().Method == http.MethodHead { // Issue #608
 			responseError = c.NoContent(code)
 		} else {
 			message := m.internalServerErrorMessage
+			if expose {
+				message = response.Message
+			}
 			switch response.Message {
 			case http.StatusText(http.StatusUnsupportedMediaType):
 				message = response.Message
@@ -407,7 +585,7 @@ func (m *Middleware) httpErrorHandler(err error, c echo.Context) {
 				break
 			}
 			response.Message = message
-			responseError = c.JSON(code, response)
+			responseError = m.responder.Render(c, code, m.responder.Wrap(response.Status, response.Message, response.Data))
 		}
 
 		var alreadyLogged bool
@@ -429,21 +607,53 @@ func (m *Middleware) httpErrorHandler(err error, c echo.Context) {
 	}
 }
 
-func (m *Middleware) healthHandler(c echo.Context) error {
+// healthHandler runs the checks of the health registry, 503 when a critical check is failing
+// healthHandler answers the report of check, the readiness URL also fails while the registry is not ready
+func (m *Middleware) healthHandler(c echo.Context, check func(ctx context.Context) health.Report) error {
 	content := c.QueryParam("content")
 	from := c.Get("RequestTime").(time.Time)
+	report := check(c.Request().Context())
+
 	data := make(map[string]interface{})
 	data["name"] = m.name
 	data["version"] = m.version
+	data["status"] = report.Status
+	data["checks"] = report.Checks
 	data["elapsed"] = time.Now().Sub(from).Nanoseconds() / 1000000
-	response := Response.CreateResponse(Response.SuccessCode, "healthy", data)
+
+	code, status, message := http.StatusOK, Response.SuccessCode, "healthy"
+	switch report.Status {
+	case health.StatusDegraded:
+		message = "degraded"
+	case health.StatusDown:
+		code, status, message = http.StatusServiceUnavailable, Response.GeneralError, "unhealthy"
+	}
+
 	if content == "true" {
-		return c.JSON(http.StatusOK, response)
+		return c.JSON(code, Response.CreateResponse(status, message, data))
+	}
+	if code == http.StatusOK {
+		return c.NoContent(http.StatusNoContent)
 	}
-	return c.NoContent(http.StatusNoContent)
+	return c.NoContent(code)
+}
+
+// livenessHandler answers as long as the server is running, dependencies are not checked
+func (m *Middleware) livenessHandler(c echo.Context) error {
+	if c.QueryParam("content") != "true" {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	data := make(map[string]interface{})
+	data["name"] = m.name
+	data["version"] = m.version
+	return c.JSON(http.StatusOK, Response.CreateResponse(Response.SuccessCode, "alive", data))
 }
 
 func (m *Middleware) skip(c echo.Context) (skip bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
 	for _, url := range m.skipURLs {
 		if strings.HasPrefix(strings.ToLower(c.Request().URL.String()), url) {
 			skip = true
@@ -474,11 +684,3 @@ func formatHeader(c echo.Context) (result map[string]interface{}) {
 	}
 	return
 }
-
-type DataValidator struct {
-	ValidatorData *validator.Validate
-}
-
-func (cv *DataValidator) Validate(i interface{}) error {
-	return cv.ValidatorData.Struct(i)
-}
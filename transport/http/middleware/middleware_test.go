@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/agitdevcenter/gopkg/availability"
+	"github.com/agitdevcenter/gopkg/health"
+	Error "github.com/agitdevcenter/gopkg/error"
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/agitdevcenter/gopkg/metrics"
+	Response "github.com/agitdevcenter/gopkg/response"
+	Session "github.com/agitdevcenter/gopkg/session"
+	ValueObject "github.com/agitdevcenter/gopkg/vo"
+	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+// tdrRecorder logger keeping the TDRs
+type tdrRecorder struct {
+	Logger.Logger
+	mutex sync.Mutex
+	tdrs  []Logger.LogTdrModel
+}
+
+func (r *tdrRecorder) TDR(tdr Logger.LogTdrModel) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.tdrs = append(r.tdrs, tdr)
+}
+
+func TestStreamTDR(t *testing.T) {
+	logger := &tdrRecorder{Logger: Logger.Noop()}
+	m := New([]Option{WithLogger(logger), WithSession(true, "payment", "1.0.0", 8080), WithErrorHandler(true)})
+
+	e := echo.New()
+	m.Setup(e)
+	e.GET("/events", func(c echo.Context) error {
+		return ValueObject.Parse(c).SSE(ValueObject.StreamOptions{}, func(stream *ValueObject.Stream) error {
+			return stream.Send(ValueObject.Event{Data: "tick"})
+		})
+	})
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.Equal(t, "data: tick\n\n", recorder.Body.String())
+
+	// the stream summary is the only TDR, body dumping does not log the stream again
+	if assert.Len(t, logger.tdrs, 1) {
+		assert.Equal(t, ValueObject.CloseCompleted, logger.tdrs[0].Response.(ValueObject.StreamSummary).CloseReason)
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	Error.Register(
+		Error.Definition{Code: "M404", HTTPStatus: http.StatusNotFound},
+		Error.Definition{Code: "M503", HTTPStatus: http.StatusServiceUnavailable},
+	)
+
+	tests := []struct {
+		name    string
+		err     error
+		code    int
+		message string
+	}{
+		{"client error", Error.New("M404", "account not found"), http.StatusNotFound, "account not found"},
+		{"server error", Error.New("M503", "dial tcp 10.0.0.1:3306: connection refused"), http.StatusServiceUnavailable, InternalServerErrorMessage},
+		{"unregistered", Error.New("M01", "sql: no rows"), http.StatusOK, InternalServerErrorMessage},
+		{"other error", errors.New("panic"), http.StatusOK, InternalServerErrorMessage},
+		{"echo error", echo.NewHTTPError(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge, InternalServerErrorMessage},
+		{"echo error without string message", echo.NewHTTPError(http.StatusBadRequest, map[string]string{"field": "id"}), http.StatusBadRequest, InternalServerErrorMessage},
+	}
+
+	for _, test := range tests {
+		m := New([]Option{WithErrorHandler(true), WithResponder(&ValueObject.Responder{StatusPolicy: ValueObject.RegistryStatusPolicy})})
+		e := echo.New()
+		m.Setup(e)
+		e.GET("/accounts", func(c echo.Context) error {
+			return test.err
+		})
+
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/accounts", nil))
+		assert.Equal(t, test.code, recorder.Code, test.name)
+
+		var response Response.DefaultResponse
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response), test.name)
+		assert.Equal(t, test.message, response.Message, test.name)
+	}
+}
+
+func TestErrorHandlerStatusPolicy(t *testing.T) {
+	Error.Register(
+		Error.Definition{Code: "M404", HTTPStatus: http.StatusNotFound},
+		Error.Definition{Code: "M503", HTTPStatus: http.StatusServiceUnavailable},
+	)
+
+	policies := map[string]ValueObject.StatusPolicy{
+		"ok":       ValueObject.OkStatusPolicy,
+		"registry": ValueObject.RegistryStatusPolicy,
+	}
+	errs := map[string]error{
+		"client error": Error.New("M404", "account not found"),
+		"server error": Error.New("M503", "connection refused"),
+		"unregistered": Error.New("M01", "sql: no rows"),
+		"wrapped":      fmt.Errorf("inquiry : %w", Error.New("M404", "account not found")),
+		"other error":  errors.New("panic"),
+	}
+
+	for policyName, policy := range policies {
+		for errName, err := range errs {
+			m := New([]Option{WithErrorHandler(true), WithResponder(&ValueObject.Responder{StatusPolicy: policy})})
+			e := echo.New()
+			m.Setup(e)
+			e.GET("/returned", func(c echo.Context) error {
+				return err
+			})
+			e.GET("/rendered", func(c echo.Context) error {
+				return ValueObject.Parse(c).Error(err, nil)
+			})
+
+			returned := httptest.NewRecorder()
+			e.ServeHTTP(returned, httptest.NewRequest(http.MethodGet, "/returned", nil))
+			rendered := httptest.NewRecorder()
+			e.ServeHTTP(rendered, httptest.NewRequest(http.MethodGet, "/rendered", nil))
+
+			assert.Equal(t, rendered.Code, returned.Code, policyName+" "+errName)
+			if policyName == "ok" {
+				assert.Equal(t, http.StatusOK, returned.Code, policyName+" "+errName)
+			}
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	backend := availability.NewMemory()
+	opts := []Option{WithSkip([]string{"/ping"})}
+	m := New([]Option{WithReload(func() ([]Option, error) { return opts, nil })})
+	assert.Nil(t, m.Availability())
+
+	// enabling the availability creates it with its options
+	opts = []Option{
+		WithSkip([]string{"/ping", "/version"}),
+		WithCORS(true),
+		WithAvailability("/availability"),
+		WithAvailabilityOptions(availability.WithBackend(backend)),
+		WithEndpointAvailability([]string{"/payments"}),
+	}
+	assert.NoError(t, m.Reload(context.Background()))
+	created := m.Availability()
+	assert.NotNil(t, created)
+	assert.True(t, m.cors)
+	assert.Equal(t, []string{"/ping", "/version", "/availability"}, m.skipURLs)
+
+	// the availability is kept, its endpoints follow the options
+	opts = []Option{
+		WithAvailability("/availability"),
+		WithAvailabilityOptions(availability.WithBackend(availability.NewMemory())),
+		WithEndpointAvailability([]string{"/payments", "/transfers"}),
+	}
+	assert.NoError(t, m.Reload(context.Background()))
+	assert.True(t, created == m.Availability())
+	assert.False(t, m.cors)
+	assert.Equal(t, []string{"/payments", "/transfers"}, m.endpointAvailabilityURLs)
+}
+
+func TestTracing(t *testing.T) {
+	tracer := mocktracer.New()
+
+	e := echo.New()
+	// the span started by the tracing middleware of http.WithTracing, registered before Setup
+	e.Use(func(h echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			span := tracer.StartSpan(c.Path())
+			defer span.Finish()
+			c.SetRequest(c.Request().WithContext(opentracing.ContextWithSpan(c.Request().Context(), span)))
+			return h(c)
+		}
+	})
+	m := New([]Option{WithSession(true, "payment", "1.0.0", 8080)})
+	m.Setup(e)
+
+	var traced opentracing.Span
+	var fromSession bool
+	e.GET("/users/:id", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		traced = opentracing.SpanFromContext(ctx)
+		_, fromSession = Session.FromContext(ctx)
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	// the request context keeps the span next to the session, rest calls made with it carry both
+	assert.True(t, fromSession)
+	if assert.NotNil(t, traced) {
+		assert.Equal(t, "/users/:id", traced.(*mocktracer.MockSpan).OperationName)
+	}
+}
+
+func TestHealthDuringDrain(t *testing.T) {
+	registry := health.NewRegistry()
+	assert.NoError(t, registry.Register(health.Check{Name: "mysql", Critical: true, Checker: health.CheckerFunc(func(ctx context.Context) error { return nil })}))
+
+	m := New([]Option{WithHealth("/health"), WithReadiness("/ready"), WithHealthRegistry(registry)})
+	e := echo.New()
+	m.Setup(e)
+
+	status := func(url string) int {
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, status("/health"))
+	assert.Equal(t, http.StatusNoContent, status("/ready"))
+
+	// draining fails the readiness only
+	registry.SetReady(false)
+	assert.Equal(t, http.StatusNoContent, status("/health"))
+	assert.Equal(t, http.StatusServiceUnavailable, status("/ready"))
+
+	// the registry set by the transport replaces the one of the options
+	inherited := health.NewRegistry()
+	assert.NoError(t, inherited.Register(health.Check{Name: "redis", Critical: true, Checker: health.CheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") })}))
+	m.SetHealthRegistry(inherited)
+	assert.Equal(t, http.StatusServiceUnavailable, status("/health"))
+}
+
+func TestMetrics(t *testing.T) {
+	collector, err := metrics.New(metrics.SubsystemHTTP, []metrics.Option{metrics.WithRegistry(prometheus.NewRegistry())})
+	assert.NoError(t, err)
+
+	m := New([]Option{WithMetrics(collector), WithMetricsURL(metrics.DefaultURL)})
+	e := echo.New()
+	m.Setup(e)
+	e.GET("/accounts/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "account not found")
+	})
+	e.Any("/files", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	handled := 0
+	errorHandler := e.HTTPErrorHandler
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		handled++
+		errorHandler(err, c)
+	}
+
+	serve := func(method, url string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, httptest.NewRequest(method, url, nil))
+		return recorder
+	}
+
+	// the returned error is answered once, with the observed status
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/accounts/1").Code)
+	assert.Equal(t, 1, handled)
+
+	assert.Equal(t, http.StatusOK, serve("PROPFIND", "/files").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodPut, "/files").Code)
+
+	body := serve(http.MethodGet, metrics.DefaultURL).Body.String()
+	assert.Contains(t, body, `http_requests_total{code="",method="GET",route="/accounts/:id",status="404"} 1`)
+	assert.Contains(t, body, `http_requests_total{code="",method="OTHER",route="/files",status="200"} 1`)
+	assert.Contains(t, body, `http_requests_total{code="",method="PUT",route="/files",status="200"} 1`)
+	assert.NotContains(t, body, "PROPFIND")
+}
@@ -1,7 +1,12 @@
 package middleware
 
 import (
+	"github.com/agitdevcenter/gopkg/availability"
+	"github.com/agitdevcenter/gopkg/health"
 	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/agitdevcenter/gopkg/metrics"
+	ValueObject "github.com/agitdevcenter/gopkg/vo"
+	"github.com/labstack/echo/v4/middleware"
 )
 
 type Option func(*Middleware)
@@ -36,18 +41,35 @@ func WithCORS(enabled bool) Option {
 	}
 }
 
+// WithCORSConfig enables CORS with config instead of DefaultCORSConfig
+func WithCORSConfig(config middleware.CORSConfig) Option {
+	return func(m *Middleware) {
+		m.cors = true
+		m.corsConfig = config
+	}
+}
+
 func WithGZip(enabled bool) Option {
 	return func(m *Middleware) {
 		m.gzip = enabled
 	}
 }
 
+// WithValidator enables the validator of NewDataValidator, Setup panics when it can not be created
 func WithValidator(enabled bool) Option {
 	return func(m *Middleware) {
 		m.validator = enabled
 	}
 }
 
+// WithDataValidator enables the validator with custom validations registered on dataValidator
+func WithDataValidator(dataValidator *DataValidator) Option {
+	return func(m *Middleware) {
+		m.validator = true
+		m.dataValidator = dataValidator
+	}
+}
+
 func WithErrorHandler(enabled bool) Option {
 	return func(m *Middleware) {
 		m.errorHandler = enabled
@@ -89,6 +111,48 @@ func WithHealth(url string) Option {
 	}
 }
 
+// WithLiveness url answering while the server is running, no check is run
+func WithLiveness(url string) Option {
+	return func(m *Middleware) {
+		m.livenessURL = url
+		m.skipURLs = append(m.skipURLs, url)
+	}
+}
+
+// WithReadiness url failing while a critical check is failing or the health registry is not ready, ex: during the drain period,
+// the health URL only reports the checks
+func WithReadiness(url string) Option {
+	return func(m *Middleware) {
+		m.readinessURL = url
+		m.skipURLs = append(m.skipURLs, url)
+	}
+}
+
+// WithHealthRegistry checks served on the health and readiness URL instead of health.DefaultRegistry
+func WithHealthRegistry(registry *health.Registry) Option {
+	return func(m *Middleware) {
+		if registry != nil {
+			m.healthRegistry = registry
+		}
+	}
+}
+
+// WithMetrics counts the requests and their latency by route, ex: metrics.New(metrics.SubsystemHTTP, nil)
+func WithMetrics(metrics *metrics.Metrics) Option {
+	return func(m *Middleware) {
+		m.metrics = metrics
+	}
+}
+
+// WithMetricsURL url serving the registry of the metrics given to WithMetrics, ex: metrics.DefaultURL
+func WithMetricsURL(url string) Option {
+	return func(m *Middleware) {
+		m.metricsURL = url
+	}
+}
+
+// WithAvailability url answering the availability on GET and changing it with the WithAvailabilityAuth credentials,
+// see availability.Availability.Handler. Without URL the availability is changed by the admin server only.
 func WithAvailability(url string) Option {
 	return func(m *Middleware) {
 		m.availabilityEnabled = true
@@ -96,8 +160,43 @@ func WithAvailability(url string) Option {
 	}
 }
 
+// WithAvailabilityOptions options of the availability, ex: availability.WithBackend to share it between the replicas
+func WithAvailabilityOptions(opts ...availability.Option) Option {
+	return func(m *Middleware) {
+		m.availabilityOptions = append(m.availabilityOptions, opts...)
+	}
+}
+
+// WithAvailabilityAuth changes on the availability URL are forbidden without authenticator
+func WithAvailabilityAuth(authenticate availability.Authenticator) Option {
+	return func(m *Middleware) {
+		m.availabilityAuth = authenticate
+	}
+}
+
 func WithEndpointAvailability(urls []string) Option {
 	return func(m *Middleware) {
 		m.endpointAvailabilityURLs = urls
 	}
 }
+
+func WithBaggage(keys []string) Option {
+	return func(m *Middleware) {
+		m.baggageKeys = keys
+	}
+}
+
+func WithResponder(responder *ValueObject.Responder) Option {
+	return func(m *Middleware) {
+		if responder != nil {
+			m.responder = responder
+		}
+	}
+}
+
+// WithReload load returns the whole option list given to New, Reload applies its skip list, CORS and availability settings
+func WithReload(load func() ([]Option, error)) Option {
+	return func(m *Middleware) {
+		m.reload = load
+	}
+}
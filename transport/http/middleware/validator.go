@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	ValueObject "github.com/agitdevcenter/gopkg/vo"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/spf13/cast"
+	"gopkg.in/go-playground/validator.v9"
+	enTranslations "gopkg.in/go-playground/validator.v9/translations/en"
+	idTranslations "gopkg.in/go-playground/validator.v9/translations/id"
+)
+
+const (
+	LanguageEnglish    = "en"
+	LanguageIndonesian = "id"
+
+	PhoneNumberTag = "phone"
+	AmountTag      = "amount"
+	NIKTag         = "nik"
+)
+
+var (
+	phoneNumberPattern = regexp.MustCompile(`^(\+62|62|0)8[1-9][0-9]{6,11}$`)
+	amountPattern      = regexp.MustCompile(`^[0-9]+(\.[0-9]{1,2})?$`)
+	// province, regency and district codes are never zero
+	nikPattern = regexp.MustCompile(`^[1-9][1-9](0[1-9]|[1-9][0-9]){2}[0-9]{10}$`)
+)
+
+// DataValidator echo validator with English and Indonesian translations, messages follow the
+// request Accept-Language and fall back to English
+type DataValidator struct {
+	ValidatorData *validator.Validate
+	Translator    *ut.UniversalTranslator
+}
+
+// NewDataValidator returns an error when a default translation or a built in custom tag fails to register
+func NewDataValidator() (*DataValidator, error) {
+	english := en.New()
+	translator := ut.New(english, english, id.New())
+
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	englishTranslator, _ := translator.GetTranslator(LanguageEnglish)
+	if err := enTranslations.RegisterDefaultTranslations(v, englishTranslator); err != nil {
+		return nil, fmt.Errorf("error registering %s translations : %+v", LanguageEnglish, err)
+	}
+
+	indonesianTranslator, _ := translator.GetTranslator(LanguageIndonesian)
+	if err := idTranslations.RegisterDefaultTranslations(v, indonesianTranslator); err != nil {
+		return nil, fmt.Errorf("error registering %s translations : %+v", LanguageIndonesian, err)
+	}
+
+	cv := &DataValidator{ValidatorData: v, Translator: translator}
+
+	if err := cv.Register(PhoneNumberTag, PhoneNumber, map[string]string{
+		LanguageEnglish:    "{0} must be a valid phone number",
+		LanguageIndonesian: "{0} harus berupa nomor telepon yang valid",
+	}); err != nil {
+		return nil, err
+	}
+	if err := cv.Register(AmountTag, Amount, map[string]string{
+		LanguageEnglish:    "{0} must be a valid amount",
+		LanguageIndonesian: "{0} harus berupa nominal yang valid",
+	}); err != nil {
+		return nil, err
+	}
+	if err := cv.Register(NIKTag, NIK, map[string]string{
+		LanguageEnglish:    "{0} must be a valid NIK",
+		LanguageIndonesian: "{0} harus berupa NIK yang valid",
+	}); err != nil {
+		return nil, err
+	}
+
+	return cv, nil
+}
+
+func (cv *DataValidator) Validate(i interface{}) error {
+	return cv.ValidatorData.Struct(i)
+}
+
+// Register adds a custom validation tag, messages are keyed by language and may use {0} for the field and {1} for the parameter
+func (cv *DataValidator) Register(tag string, fn validator.Func, messages map[string]string) error {
+	if err := cv.ValidatorData.RegisterValidation(tag, fn); err != nil {
+		return fmt.Errorf("error registering validation %s : %+v", tag, err)
+	}
+
+	for language, message := range messages {
+		translator, found := cv.Translator.GetTranslator(language)
+		if !found {
+			return fmt.Errorf("error registering validation %s : unsupported language %s", tag, language)
+		}
+
+		message := message
+		err := cv.ValidatorData.RegisterTranslation(tag, translator, func(t ut.Translator) error {
+			return t.Add(tag, message, true)
+		}, func(t ut.Translator, fe validator.FieldError) string {
+			translated, err := t.T(fe.Tag(), fe.Field(), fe.Param())
+			if err != nil {
+				return fmt.Sprintf("%s failed on the %s tag", fe.Field(), fe.Tag())
+			}
+			return translated
+		})
+		if err != nil {
+			return fmt.Errorf("error registering validation %s translation : %+v", tag, err)
+		}
+	}
+
+	return nil
+}
+
+// FieldErrors implements vo.FieldValidator
+func (cv *DataValidator) FieldErrors(err error, languages ...string) (fields []ValueObject.FieldError) {
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return
+	}
+
+	translator, _ := cv.Translator.FindTranslator(languages...)
+
+	for _, fe := range validationErrors {
+		fields = append(fields, ValueObject.FieldError{
+			Field:   ValueObject.FieldPath(fe.Namespace()),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(translator),
+		})
+	}
+	return
+}
+
+// PhoneNumber Indonesian mobile phone number, ex: 081234567890, +6281234567890
+func PhoneNumber(fl validator.FieldLevel) bool {
+	return phoneNumberPattern.MatchString(fl.Field().String())
+}
+
+// Amount zero or positive amount with at most two decimals, as number or numeric string, floats are
+// rounded to the significant digits of their type first so results like 0.1+0.2 are 0.3
+func Amount(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	switch field.Kind() {
+	case reflect.String:
+		return amountPattern.MatchString(field.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field.Int() >= 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Float32, reflect.Float64:
+		digits := 15
+		if field.Kind() == reflect.Float32 {
+			digits = 6
+		}
+		amount, err := strconv.ParseFloat(strconv.FormatFloat(field.Float(), 'g', digits, 64), 64)
+		return err == nil && amount >= 0 && amountPattern.MatchString(strconv.FormatFloat(amount, 'f', -1, 64))
+	}
+	return false
+}
+
+// NIK Indonesian identity number, 16 digits made of the province, regency and district codes, the birth
+// date ddmmyy (day + 40 for women) and a serial number
+func NIK(fl validator.FieldLevel) bool {
+	nik := fl.Field().String()
+	if !nikPattern.MatchString(nik) || nik[12:] == "0000" {
+		return false
+	}
+
+	day := cast.ToInt(strings.TrimLeft(nik[6:8], "0"))
+	if day > 40 {
+		day -= 40
+	}
+	month := cast.ToInt(strings.TrimLeft(nik[8:10], "0"))
+	if month < 1 || month > 12 {
+		return false
+	}
+
+	// the century is unknown so 29 february is always accepted, 2000 being a leap year
+	days := time.Date(2000, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	return day >= 1 && day <= days
+}
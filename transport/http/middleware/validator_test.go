@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhoneNumber(t *testing.T) {
+	v, err := NewDataValidator()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		phone string
+		valid bool
+	}{
+		{"081234567890", true},
+		{"6281234567890", true},
+		{"+6281234567890", true},
+		{"081234567", true},
+		{"08123456", false},
+		{"0812345678901234", false},
+		{"0801234567890", false},
+		{"021234567890", false},
+		{"+6581234567890", false},
+		{"08123456789a", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		err := v.ValidatorData.Var(test.phone, PhoneNumberTag)
+		assert.Equal(t, test.valid, err == nil, test.phone)
+	}
+}
+
+func TestAmount(t *testing.T) {
+	v, err := NewDataValidator()
+	assert.NoError(t, err)
+
+	// 0.30000000000000004 once computed at run time
+	tenth := 0.1
+
+	tests := []struct {
+		amount interface{}
+		valid  bool
+	}{
+		{"10000", true},
+		{"10000.5", true},
+		{"10000.50", true},
+		{"10000.505", false},
+		{"-10000", false},
+		{"1e4", false},
+		{"", false},
+		{10000, true},
+		{-1, false},
+		{uint(10000), true},
+		{10000.5, true},
+		{10000.505, false},
+		{-0.5, false},
+		{tenth + 2*tenth, true},
+		{float32(0.3), true},
+		{float32(10.505), false},
+		{0, true},
+		{"0", true},
+		{0.0, true},
+		{1e21, true},
+		{math.NaN(), false},
+		{math.Inf(1), false},
+		{true, false},
+	}
+
+	for _, test := range tests {
+		err := v.ValidatorData.Var(test.amount, AmountTag)
+		assert.Equal(t, test.valid, err == nil, "%v", test.amount)
+	}
+}
+
+func TestNIK(t *testing.T) {
+	v, err := NewDataValidator()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		nik   string
+		valid bool
+	}{
+		{"3171011708450001", true},
+		{"3171015708450001", true}, // woman born on the 17th
+		{"3171012902000001", true},
+		{"3171013112990001", true},
+		{"3171017112990001", true},
+		{"317101170845000", false},
+		{"31710117084500011", false},
+		{"317101170845000a", false},
+		{"0171011708450001", false}, // province
+		{"3071011708450001", false},
+		{"3100011708450001", false}, // regency
+		{"3171001708450001", false}, // district
+		{"3171010008450001", false}, // day
+		{"3171013208450001", false},
+		{"3171014008450001", false},
+		{"3171017208450001", false},
+		{"3171013002000001", false},
+		{"3171013104000001", false},
+		{"3171011700450001", false}, // month
+		{"3171011713450001", false},
+		{"3171011708450000", false}, // serial
+	}
+
+	for _, test := range tests {
+		err := v.ValidatorData.Var(test.nik, NIKTag)
+		assert.Equal(t, test.valid, err == nil, test.nik)
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	v, err := NewDataValidator()
+	assert.NoError(t, err)
+
+	type request struct {
+		Phone  string `json:"phone" validate:"phone"`
+		Amount string `json:"amount" validate:"amount"`
+		NIK    string `json:"nik" validate:"nik"`
+		Name   string `json:"name" validate:"required"`
+	}
+
+	err = v.Validate(request{Phone: "12345", Amount: "abc", NIK: "123"})
+	assert.Error(t, err)
+
+	tests := []struct {
+		languages []string
+		messages  []string
+	}{
+		{
+			[]string{LanguageEnglish},
+			[]string{"phone must be a valid phone number", "amount must be a valid amount", "nik must be a valid NIK", "name is a required field"},
+		},
+		{
+			[]string{LanguageIndonesian},
+			[]string{"phone harus berupa nomor telepon yang valid", "amount harus berupa nominal yang valid", "nik harus berupa NIK yang valid", "name wajib diisi"},
+		},
+		{
+			[]string{"fr", LanguageIndonesian},
+			[]string{"phone harus berupa nomor telepon yang valid", "amount harus berupa nominal yang valid", "nik harus berupa NIK yang valid", "name wajib diisi"},
+		},
+		{
+			[]string{"fr"},
+			[]string{"phone must be a valid phone number", "amount must be a valid amount", "nik must be a valid NIK", "name is a required field"},
+		},
+	}
+
+	for _, test := range tests {
+		fields := v.FieldErrors(err, test.languages...)
+		var messages []string
+		for _, field := range fields {
+			messages = append(messages, field.Message)
+		}
+		assert.Equal(t, test.messages, messages, "%v", test.languages)
+	}
+
+	fields := v.FieldErrors(err, LanguageEnglish)
+	assert.Equal(t, "phone", fields[0].Field)
+	assert.Equal(t, PhoneNumberTag, fields[0].Rule)
+}
+
+func TestRegister(t *testing.T) {
+	v, err := NewDataValidator()
+	assert.NoError(t, err)
+
+	assert.Error(t, v.Register("", PhoneNumber, nil))
+	assert.Error(t, v.Register("merchant", PhoneNumber, map[string]string{"fr": "{0} doit être valide"}))
+}
@@ -4,6 +4,7 @@ import (
 	Logger "github.com/agitdevcenter/gopkg/logger"
 	Middleware "github.com/agitdevcenter/gopkg/transport/http/middleware"
 	"github.com/agitdevcenter/gopkg/transport/http/router"
+	"net"
 	"time"
 )
 
@@ -108,3 +109,17 @@ func WithTracing(tracing bool, skipTracingURLs []string, tracingName string) Opt
 		s.tracingName = tracingName
 	}
 }
+
+// WithListener serves on listener instead of listening on the address
+func WithListener(listener net.Listener) Option {
+	return func(s *Server) {
+		s.listener = listener
+	}
+}
+
+// WithUnixSocket listens on the unix domain socket at path instead of host and port
+func WithUnixSocket(path string) Option {
+	return func(s *Server) {
+		s.socket = path
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"github.com/agitdevcenter/gopkg/crypto/certificate"
+	"github.com/agitdevcenter/gopkg/health"
 	Logger "github.com/agitdevcenter/gopkg/logger"
 	Middleware "github.com/agitdevcenter/gopkg/transport/http/middleware"
 	Router "github.com/agitdevcenter/gopkg/transport/http/router"
@@ -45,9 +47,17 @@ type Server struct {
 	gracefulShutdownTime    time.Duration
 	router                  Router.Router
 	middleware              *Middleware.Middleware
+	healthRegistry          *health.Registry
 	tracing                 bool
 	tracingName             string
 	skipTracingURLs         []string
+	mutex                   sync.RWMutex
+	keypair                 *certificate.Keypair
+	listener                net.Listener
+	socket                  string
+	addr                    net.Addr
+	ready                   chan struct{}
+	readyOnce               sync.Once
 }
 
 func New(opts []Option) *Server {
@@ -55,6 +65,7 @@ func New(opts []Option) *Server {
 		port:                 2202,
 		gracefulShutdownTime: 5 * time.Second,
 		inherit:              true,
+		ready:                make(chan struct{}),
 	}
 
 	for _, opt := range opts {
@@ -86,6 +97,9 @@ func (s *Server) inheritMiddleware() {
 		s.middleware.SetDebug(s.debug)
 		s.middleware.SetLogger(s.logger)
 		s.middleware.SetPort(s.port)
+		if s.healthRegistry != nil {
+			s.middleware.SetHealthRegistry(s.healthRegistry)
+		}
 	}
 }
 
@@ -94,22 +108,108 @@ func (s *Server) SetMiddleware(middleware *Middleware.Middleware) {
 	s.inheritMiddleware()
 }
 
+// SetHealthRegistry checks served on the health and readiness URL of the middleware
+func (s *Server) SetHealthRegistry(registry *health.Registry) {
+	s.healthRegistry = registry
+	s.inheritMiddleware()
+}
+
+func (s *Server) Middleware() *Middleware.Middleware {
+	return s.middleware
+}
+
+// Reload reads the TLS certificate files again and reloads the middleware, connections are kept
+func (s *Server) Reload(ctx context.Context) error {
+	s.mutex.RLock()
+	keypair := s.keypair
+	s.mutex.RUnlock()
+
+	if keypair != nil {
+		if err := keypair.Reload(); err != nil {
+			return fmt.Errorf("reloading http server certificate : %+v", err)
+		}
+	}
+
+	if s.middleware != nil {
+		if err := s.middleware.Reload(ctx); err != nil {
+			return err
+		}
+	}
+
+	if s.debug {
+		s.logger.Info(fmt.Sprintf("http server on %s reloaded", s.Address()))
+	}
+
+	return nil
+}
+
+// SetListener serves on listener instead of listening on the address, ex: a listener inherited from the parent process
+func (s *Server) SetListener(listener net.Listener) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listener = listener
+}
+
+// Listener the listener being served, nil before the server is started
+func (s *Server) Listener() net.Listener {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.listener
+}
+
+func (s *Server) listen() (net.Listener, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener == nil {
+		network := "tcp"
+		if len(s.socket) > 0 {
+			network = "unix"
+			// socket file left by a process that did not stop cleanly
+			if info, err := os.Stat(s.socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+				os.Remove(s.socket)
+			}
+		}
+
+		listener, err := net.Listen(network, s.Address())
+		if err != nil {
+			return nil, err
+		}
+		s.listener = listener
+	}
+
+	s.addr = s.listener.Addr()
+	s.readyOnce.Do(func() {
+		close(s.ready)
+	})
+
+	return s.listener, nil
+}
+
+// Address configured address, host:port or the unix socket path, see Addr for the bound address
 func (s *Server) Address() string {
+	if len(s.socket) > 0 {
+		return s.socket
+	}
 	return fmt.Sprintf("%s:%d", s.host, s.port)
 }
 
+// Addr address the server is bound to, ex: the port chosen when port is 0, nil before the server is listening
+func (s *Server) Addr() net.Addr {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.addr
+}
+
+// Ready closed once the server is listening, see custom.Readiness
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
 func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
 	s.inheritMiddleware()
 	return func() error {
 
-		if checkPort, err := net.Listen("tcp", s.Address()); err != nil {
-			return fmt.Errorf("%s already in use, error : %+v", s.Address(), err)
-		} else {
-			if err := checkPort.Close(); err != nil {
-				return fmt.Errorf("closing http connection on %s, error : %+v", s.Address(), err)
-			}
-		}
-
 		echo := Echo.New()
 
 		var tracing io.Closer
@@ -167,12 +267,19 @@ func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
 			wg.Done()
 		}()
 
-		if s.debug {
-			s.logger.Info(fmt.Sprintf("starting http server on %s", s.Address()))
-		}
-
-		var errorStartingServer error
 		if s.tls {
+			keypair, err := certificate.NewKeypair(s.certificateFile, s.keyFile)
+			if err != nil {
+				return fmt.Errorf("starting http server error : %+v", err)
+			}
+			s.mutex.Lock()
+			s.keypair = keypair
+			s.mutex.Unlock()
+
+			// the certificate is read through the keypair so Reload can replace it
+			tlsConfig := &tls.Config{
+				GetCertificate: keypair.GetCertificate,
+			}
 			if len(s.rootCAFile) > 0 {
 				certPool := x509.NewCertPool()
 				ca, err := ioutil.ReadFile(s.rootCAFile)
@@ -182,13 +289,25 @@ func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
 				if ok := certPool.AppendCertsFromPEM(ca); !ok {
 					return fmt.Errorf("starting http server error : append certs from pem")
 				}
-				echo.Server.TLSConfig = &tls.Config{
-					ServerName:         s.serverName,
-					RootCAs:            certPool,
-					InsecureSkipVerify: s.insecureSkipVerify,
-				}
+				tlsConfig.ServerName = s.serverName
+				tlsConfig.RootCAs = certPool
+				tlsConfig.InsecureSkipVerify = s.insecureSkipVerify
 			}
-			errorStartingServer = echo.Server.ListenAndServeTLS(s.certificateFile, s.keyFile)
+			echo.Server.TLSConfig = tlsConfig
+		}
+
+		listener, err := s.listen()
+		if err != nil {
+			return fmt.Errorf("%s already in use, error : %+v", s.Address(), err)
+		}
+
+		if s.debug {
+			s.logger.Info(fmt.Sprintf("starting http server on %s", listener.Addr()))
+		}
+
+		var errorStartingServer error
+		if s.tls {
+			errorStartingServer = echo.Server.ServeTLS(listener, "", "")
 		} else if s.h2c {
 			h2c := &http2.Server{
 				MaxConcurrentStreams: s.h2cMaxConcurrentStreams,
@@ -202,11 +321,15 @@ func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
 			echo.HideBanner = true
 			echo.HidePort = true
 
+			echo.Listener = listener
 			errorStartingServer = echo.StartH2CServer(s.Address(), h2c)
 		} else {
-			errorStartingServer = echo.Server.ListenAndServe()
+			errorStartingServer = echo.Server.Serve(listener)
 		}
 
+		// the listener is closed with the server
+		s.SetListener(nil)
+
 		if errorStartingServer != http.ErrServerClosed {
 			return fmt.Errorf("starting http server error : %+v", errorStartingServer)
 		}
@@ -215,7 +338,7 @@ func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
 			s.logger.Info(fmt.Sprintf("http server stopped on : %s", s.Address()))
 		}
 
-		err := <-errorServer
+		err = <-errorServer
 		wg.Wait()
 		return err
 	}
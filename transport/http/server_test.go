@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	Echo "github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type pingRouter struct{}
+
+func (pingRouter) Route(e *Echo.Echo) {
+	e.GET("/ping", func(c Echo.Context) error {
+		return c.String(http.StatusOK, "pong")
+	})
+}
+
+// start runs s until the returned stop is called
+func start(t *testing.T, s *Server) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Start(ctx, &wg)()
+	}()
+
+	select {
+	case <-s.Ready():
+	case err := <-done:
+		t.Fatal(err)
+	}
+
+	return func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	response, err := client.Get(url)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestServerDynamicPort(t *testing.T) {
+	first := New([]Option{WithAddress("127.0.0.1", 0), WithRouter(pingRouter{})})
+	second := New([]Option{WithAddress("127.0.0.1", 0), WithRouter(pingRouter{})})
+	assert.Nil(t, first.Addr())
+
+	defer start(t, first)()
+	defer start(t, second)()
+
+	assert.NotEqual(t, first.Addr().String(), second.Addr().String())
+	assert.Equal(t, "pong", get(t, http.DefaultClient, "http://"+first.Addr().String()+"/ping"))
+	assert.Equal(t, "pong", get(t, http.DefaultClient, "http://"+second.Addr().String()+"/ping"))
+}
+
+func TestServerUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "http.sock")
+	s := New([]Option{WithUnixSocket(socket), WithRouter(pingRouter{})})
+	defer start(t, s)()
+
+	assert.Equal(t, socket, s.Addr().String())
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	assert.Equal(t, "pong", get(t, client, "http://unix/ping"))
+}
+
+func TestServerListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	s := New([]Option{WithListener(listener), WithRouter(pingRouter{})})
+	defer start(t, s)()
+
+	assert.Equal(t, listener.Addr(), s.Addr())
+	assert.Equal(t, "pong", get(t, http.DefaultClient, "http://"+listener.Addr().String()+"/ping"))
+}
@@ -0,0 +1,427 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agitdevcenter/gopkg/backoff"
+	Error "github.com/agitdevcenter/gopkg/error"
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Response "github.com/agitdevcenter/gopkg/response"
+	Session "github.com/agitdevcenter/gopkg/session"
+	"github.com/agitdevcenter/gopkg/utils"
+	Kafka "github.com/segmentio/kafka-go"
+)
+
+const (
+	// HeaderThreadID header of the thread ID, the same as the X-Request-ID of the HTTP requests
+	HeaderThreadID = "X-Request-ID"
+
+	// HeaderTopic, HeaderPartition, HeaderOffset and HeaderError added to the messages published to the dead letter topic
+	HeaderTopic     = "X-Original-Topic"
+	HeaderPartition = "X-Original-Partition"
+	HeaderOffset    = "X-Original-Offset"
+	HeaderError     = "X-Error"
+
+	Name               = "LinkAja"
+	Version            = "1.0.0"
+	DefaultConcurrency = 1
+	DefaultAttempts    = 3
+)
+
+// Handler processes a message, the message is committed once Handle returns nil.
+// The session is also in ctx, see session.FromContext.
+type Handler interface {
+	Handle(ctx context.Context, session *Session.Session, message Kafka.Message) error
+}
+
+type HandlerFunc func(ctx context.Context, session *Session.Session, message Kafka.Message) error
+
+func (f HandlerFunc) Handle(ctx context.Context, session *Session.Session, message Kafka.Message) error {
+	return f(ctx, session, message)
+}
+
+// reader of one topic, implemented by kafka.Reader
+type reader interface {
+	FetchMessage(ctx context.Context) (Kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...Kafka.Message) error
+	Close() error
+}
+
+// writer of one topic, implemented by kafka.Writer
+type writer interface {
+	WriteMessages(ctx context.Context, msgs ...Kafka.Message) error
+	Close() error
+}
+
+type job struct {
+	reader  reader
+	message Kafka.Message
+}
+
+// Consumer custom.Service consuming topics in a consumer group, messages of a partition are handled in order
+type Consumer struct {
+	logger          Logger.Logger
+	debug           bool
+	name            string
+	version         string
+	brokers         []string
+	groupID         string
+	topics          []string
+	config          Kafka.ReaderConfig
+	handler         Handler
+	concurrency     int
+	policy          backoff.Policy
+	attempts        int
+	retryable       func(error) bool
+	deadLetterTopic string
+
+	newReader func(config Kafka.ReaderConfig) reader
+	newWriter func(config Kafka.WriterConfig) writer
+
+	ready     chan struct{}
+	readyOnce sync.Once
+	mutex     sync.RWMutex
+	err       error
+}
+
+func NewConsumer(opts []Option) *Consumer {
+	c := &Consumer{
+		name:        Name,
+		version:     Version,
+		concurrency: DefaultConcurrency,
+		policy:      backoff.Default,
+		attempts:    DefaultAttempts,
+		newReader: func(config Kafka.ReaderConfig) reader {
+			return Kafka.NewReader(config)
+		},
+		newWriter: func(config Kafka.WriterConfig) writer {
+			return Kafka.NewWriter(config)
+		},
+		ready: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.logger == nil {
+		c.logger = Logger.Noop()
+	}
+
+	return c
+}
+
+func (c *Consumer) SetLogger(logger Logger.Logger) {
+	c.logger = logger
+}
+
+func (c *Consumer) SetDebug(enabled bool) {
+	c.debug = enabled
+}
+
+// Ready is closed once the readers are created
+func (c *Consumer) Ready() <-chan struct{} {
+	return c.ready
+}
+
+// Check fails once the consumer stopped on an error or when no broker is reachable
+func (c *Consumer) Check(ctx context.Context) error {
+	c.mutex.RLock()
+	err := c.err
+	c.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err = c.validate(); err != nil {
+		return err
+	}
+
+	dialer := c.config.Dialer
+	if dialer == nil {
+		dialer = Kafka.DefaultDialer
+	}
+
+	for _, broker := range c.brokers {
+		var conn *Kafka.Conn
+		if conn, err = dialer.DialContext(ctx, "tcp", broker); err == nil {
+			return conn.Close()
+		}
+	}
+	return fmt.Errorf("no kafka broker reachable : %+v", err)
+}
+
+func (c *Consumer) validate() error {
+	switch {
+	case len(c.brokers) == 0:
+		return errors.New("kafka consumer without brokers")
+	case len(c.groupID) == 0:
+		return errors.New("kafka consumer without group")
+	case len(c.topics) == 0:
+		return errors.New("kafka consumer without topics")
+	case c.handler == nil:
+		return errors.New("kafka consumer without handler")
+	}
+	return nil
+}
+
+func (c *Consumer) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
+	return func() error {
+		defer wg.Done()
+
+		if err := c.validate(); err != nil {
+			return err
+		}
+
+		consumeContext, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var failure error
+		var failOnce sync.Once
+		fail := func(err error) {
+			failOnce.Do(func() {
+				failure = err
+				c.mutex.Lock()
+				c.err = err
+				c.mutex.Unlock()
+				cancel()
+			})
+		}
+
+		readers := make([]reader, len(c.topics))
+		for i, topic := range c.topics {
+			config := c.config
+			config.Brokers = c.brokers
+			config.GroupID = c.groupID
+			config.Topic = topic
+			readers[i] = c.newReader(config)
+		}
+
+		var deadLetter writer
+		if len(c.deadLetterTopic) > 0 {
+			deadLetter = c.newWriter(Kafka.WriterConfig{Brokers: c.brokers, Topic: c.deadLetterTopic, Dialer: c.config.Dialer})
+		}
+
+		workers := make([]chan job, c.concurrency)
+		var working sync.WaitGroup
+		for i := range workers {
+			workers[i] = make(chan job)
+			working.Add(1)
+			go func(jobs <-chan job) {
+				defer working.Done()
+				for j := range jobs {
+					if err := c.process(consumeContext, j, deadLetter); err != nil {
+						fail(err)
+					}
+				}
+			}(workers[i])
+		}
+
+		var fetching sync.WaitGroup
+		for _, r := range readers {
+			fetching.Add(1)
+			go func(r reader) {
+				defer fetching.Done()
+				c.fetch(consumeContext, r, workers)
+			}(r)
+		}
+
+		if c.debug {
+			c.logger.Info(fmt.Sprintf("kafka consumer of %s started in group %s", strings.Join(c.topics, ", "), c.groupID))
+		}
+		c.readyOnce.Do(func() {
+			close(c.ready)
+		})
+
+		fetching.Wait()
+		for _, jobs := range workers {
+			close(jobs)
+		}
+		working.Wait()
+
+		// readers are closed after the last commit
+		for i, r := range readers {
+			if err := r.Close(); err != nil && failure == nil {
+				failure = fmt.Errorf("error closing kafka reader of %s : %+v", c.topics[i], err)
+			}
+		}
+		if deadLetter != nil {
+			if err := deadLetter.Close(); err != nil && failure == nil {
+				failure = fmt.Errorf("error closing kafka dead letter writer : %+v", err)
+			}
+		}
+
+		if c.debug {
+			c.logger.Info("kafka consumer stopped")
+		}
+
+		return failure
+	}
+}
+
+// fetch dispatches the messages of r to the worker of their partition until ctx is done
+func (c *Consumer) fetch(ctx context.Context, r reader, workers []chan job) {
+	failures := 0
+	for {
+		message, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			failures++
+			c.logger.Error(fmt.Sprintf("error fetching kafka message : %+v", err))
+			select {
+			case <-time.After(c.policy.Duration(failures)):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		failures = 0
+
+		select {
+		case workers[worker(message, len(workers))] <- job{reader: r, message: message}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// worker index of the partition of message, a partition is always handled by the same worker
+func worker(message Kafka.Message, workers int) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(message.Topic))
+	return int((hash.Sum32() + uint32(message.Partition)) % uint32(workers))
+}
+
+// process handles the message with retries, publishes it to the dead letter topic when every attempt failed then commits it.
+// The message is not committed when the consumer stops first, the error stops the consumer when it is not handled.
+func (c *Consumer) process(ctx context.Context, j job, deadLetter writer) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	message := j.message
+	session := c.session(message)
+	session.T1("Incoming Message")
+
+	// the handler is not cancelled by the shutdown so the message in progress is completed
+	handlerContext := Session.NewContext(context.Background(), session)
+
+	var err error
+	for attempt := 0; c.attempts <= 0 || attempt < c.attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(c.policy.Duration(attempt)):
+			case <-ctx.Done():
+				c.end(session, err)
+				return nil
+			}
+		}
+
+		session.Put("attempts", attempt+1)
+		if err = c.handle(handlerContext, session, message); err == nil {
+			break
+		}
+
+		session.Error(fmt.Sprintf("attempt %d failed : %+v", attempt+1, err))
+		if c.retryable != nil && !c.retryable(err) {
+			break
+		}
+	}
+
+	if err != nil {
+		c.end(session, err)
+		if deadLetter == nil {
+			return fmt.Errorf("error handling kafka message of %s partition %d offset %d : %+v", message.Topic, message.Partition, message.Offset, err)
+		}
+		if deadLetterErr := deadLetter.WriteMessages(context.Background(), c.deadLetter(message, err)); deadLetterErr != nil {
+			return fmt.Errorf("error publishing kafka message of %s partition %d offset %d to %s : %+v", message.Topic, message.Partition, message.Offset, c.deadLetterTopic, deadLetterErr)
+		}
+	} else {
+		c.end(session, nil)
+	}
+
+	// a failed commit is not fatal, the message is delivered again after a rebalance
+	if err := j.reader.CommitMessages(context.Background(), message); err != nil {
+		c.logger.Error(fmt.Sprintf("error committing kafka message of %s partition %d offset %d : %+v", message.Topic, message.Partition, message.Offset, err))
+	}
+
+	return nil
+}
+
+// handle calls the handler, a panic is returned as an error
+func (c *Consumer) handle(ctx context.Context, session *Session.Session, message Kafka.Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("kafka handler panic : %+v", r)
+		}
+	}()
+	return c.handler.Handle(ctx, session, message)
+}
+
+func (c *Consumer) session(message Kafka.Message) *Session.Session {
+	header := make(map[string]interface{}, len(message.Headers))
+	threadID := ""
+	for _, h := range message.Headers {
+		header[h.Key] = string(h.Value)
+		if strings.EqualFold(h.Key, HeaderThreadID) {
+			threadID = string(h.Value)
+		}
+	}
+	if len(threadID) == 0 {
+		threadID = utils.GenerateThreadId()
+	}
+
+	session := Session.New(c.logger).
+		SetAppName(c.name).
+		SetAppVersion(c.version).
+		SetURL(message.Topic).
+		SetMethod("Kafka").
+		SetThreadID(threadID).
+		SetHeader(header).
+		SetRequest(string(message.Value))
+
+	session.Put("partition", message.Partition)
+	session.Put("offset", message.Offset)
+	session.Put("key", string(message.Key))
+
+	return session
+}
+
+// end writes the TDR of the message, the response code is the one of the application error
+func (c *Consumer) end(session *Session.Session, err error) {
+	if err == nil {
+		session.SetResponseCode(Response.SuccessCode)
+		session.T4(Response.CreateResponse(Response.SuccessCode, "OK", struct{}{}))
+		return
+	}
+
+	code := Response.GeneralError
+	if he, ok := Error.As(err); ok {
+		code = he.ErrorCode
+	}
+
+	session.SetErrorMessage(err.Error())
+	session.SetResponseCode(code)
+	session.T4(Response.CreateResponse(code, err.Error(), struct{}{}))
+}
+
+// deadLetter copy of message with its origin and the error of the last attempt in the headers
+func (c *Consumer) deadLetter(message Kafka.Message, err error) Kafka.Message {
+	headers := append(append([]Kafka.Header{}, message.Headers...),
+		Kafka.Header{Key: HeaderTopic, Value: []byte(message.Topic)},
+		Kafka.Header{Key: HeaderPartition, Value: []byte(strconv.Itoa(message.Partition))},
+		Kafka.Header{Key: HeaderOffset, Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		Kafka.Header{Key: HeaderError, Value: []byte(err.Error())},
+	)
+
+	return Kafka.Message{Key: message.Key, Value: message.Value, Headers: headers}
+}
@@ -0,0 +1,160 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agitdevcenter/gopkg/backoff"
+	Session "github.com/agitdevcenter/gopkg/session"
+	Kafka "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeReader struct {
+	messages  chan Kafka.Message
+	mutex     sync.Mutex
+	committed []Kafka.Message
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (Kafka.Message, error) {
+	select {
+	case message := <-r.messages:
+		return message, nil
+	case <-ctx.Done():
+		return Kafka.Message{}, ctx.Err()
+	}
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...Kafka.Message) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	return nil
+}
+
+func (r *fakeReader) offsets() (offsets []int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, message := range r.committed {
+		offsets = append(offsets, message.Offset)
+	}
+	return
+}
+
+type fakeWriter struct {
+	mutex    sync.Mutex
+	messages []Kafka.Message
+	err      error
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...Kafka.Message) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+func newTestConsumer(r *fakeReader, w *fakeWriter, opts ...Option) *Consumer {
+	c := NewConsumer(append([]Option{
+		WithBrokers("localhost:9092"),
+		WithGroup("test"),
+		WithTopics("payment"),
+		WithRetry(backoff.Policy{Millis: []int{0, 1}}, 3),
+	}, opts...))
+	c.newReader = func(config Kafka.ReaderConfig) reader { return r }
+	c.newWriter = func(config Kafka.WriterConfig) writer { return w }
+	return c
+}
+
+func start(c *Consumer) (stop func() error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Start(ctx, &wg)()
+	}()
+	<-c.Ready()
+	return func() error {
+		cancel()
+		return <-done
+	}
+}
+
+func TestConsumerOrder(t *testing.T) {
+	r := &fakeReader{messages: make(chan Kafka.Message, 10)}
+	var mutex sync.Mutex
+	handled := map[int][]int64{}
+	c := newTestConsumer(r, nil, WithConcurrency(4), WithHandler(HandlerFunc(func(ctx context.Context, session *Session.Session, message Kafka.Message) error {
+		s, ok := Session.FromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "thread", s.ThreadID)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		handled[message.Partition] = append(handled[message.Partition], message.Offset)
+		return nil
+	})))
+	stop := start(c)
+
+	for offset := int64(0); offset < 5; offset++ {
+		for partition := 0; partition < 2; partition++ {
+			r.messages <- Kafka.Message{Topic: "payment", Partition: partition, Offset: offset, Headers: []Kafka.Header{{Key: "x-request-id", Value: []byte("thread")}}}
+		}
+	}
+
+	assert.Eventually(t, func() bool { return len(r.offsets()) == 10 }, time.Second, time.Millisecond)
+	assert.NoError(t, stop())
+	assert.Equal(t, []int64{0, 1, 2, 3, 4}, handled[0])
+	assert.Equal(t, []int64{0, 1, 2, 3, 4}, handled[1])
+}
+
+func TestConsumerDeadLetter(t *testing.T) {
+	r := &fakeReader{messages: make(chan Kafka.Message, 1)}
+	w := &fakeWriter{}
+	attempts := 0
+	c := newTestConsumer(r, w, WithDeadLetter("payment-dlq"), WithHandler(HandlerFunc(func(ctx context.Context, session *Session.Session, message Kafka.Message) error {
+		attempts++
+		return errors.New("failed")
+	})))
+	stop := start(c)
+
+	r.messages <- Kafka.Message{Topic: "payment", Partition: 1, Offset: 7, Value: []byte("{}")}
+
+	assert.Eventually(t, func() bool { return len(r.offsets()) == 1 }, time.Second, time.Millisecond)
+	assert.NoError(t, stop())
+	assert.Equal(t, 3, attempts)
+	assert.Len(t, w.messages, 1)
+	assert.Equal(t, []byte("{}"), w.messages[0].Value)
+	assert.Contains(t, w.messages[0].Headers, Kafka.Header{Key: HeaderOffset, Value: []byte("7")})
+}
+
+func TestConsumerFailure(t *testing.T) {
+	r := &fakeReader{messages: make(chan Kafka.Message, 2)}
+	c := newTestConsumer(r, nil, WithRetryable(func(err error) bool { return false }), WithHandler(HandlerFunc(func(ctx context.Context, session *Session.Session, message Kafka.Message) error {
+		panic("boom")
+	})))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	r.messages <- Kafka.Message{Topic: "payment", Offset: 1}
+	err := c.Start(context.Background(), &wg)()
+
+	assert.Error(t, err)
+	assert.Empty(t, r.offsets())
+	assert.Error(t, c.Check(context.Background()))
+}
@@ -0,0 +1,159 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/agitdevcenter/gopkg/backoff"
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Kafka "github.com/segmentio/kafka-go"
+)
+
+type Option func(*Consumer)
+
+func WithDebug(enabled bool) Option {
+	return func(c *Consumer) {
+		c.debug = enabled
+	}
+}
+
+func WithLogger(logger Logger.Logger) Option {
+	return func(c *Consumer) {
+		c.logger = logger
+	}
+}
+
+// WithSession application name and version of the TDR
+func WithSession(name, version string) Option {
+	return func(c *Consumer) {
+		c.name = name
+		c.version = version
+	}
+}
+
+func WithBrokers(brokers ...string) Option {
+	return func(c *Consumer) {
+		c.brokers = append(c.brokers, brokers...)
+	}
+}
+
+func WithGroup(groupID string) Option {
+	return func(c *Consumer) {
+		c.groupID = groupID
+	}
+}
+
+func WithTopics(topics ...string) Option {
+	return func(c *Consumer) {
+		c.topics = append(c.topics, topics...)
+	}
+}
+
+// WithReaderConfig settings of the readers, ex: Dialer for TLS and SASL or MaxWait, brokers, group and topic are set by the consumer
+func WithReaderConfig(config Kafka.ReaderConfig) Option {
+	return func(c *Consumer) {
+		c.config = config
+	}
+}
+
+func WithHandler(handler Handler) Option {
+	return func(c *Consumer) {
+		c.handler = handler
+	}
+}
+
+// WithConcurrency number of partitions handled at the same time, default is DefaultConcurrency
+func WithConcurrency(concurrency int) Option {
+	return func(c *Consumer) {
+		if concurrency > 0 {
+			c.concurrency = concurrency
+		}
+	}
+}
+
+// WithRetry delays between the attempts of a message and maximum attempts, zero retries until the consumer stops.
+// Default is backoff.Default and DefaultAttempts.
+func WithRetry(policy backoff.Policy, attempts int) Option {
+	return func(c *Consumer) {
+		if len(policy.Millis) > 0 {
+			c.policy = policy
+		}
+		c.attempts = attempts
+	}
+}
+
+// WithRetryable errors attempted again, ex: Error.IsRetryable, default is every error
+func WithRetryable(retryable func(error) bool) Option {
+	return func(c *Consumer) {
+		c.retryable = retryable
+	}
+}
+
+// WithDeadLetter topic of the messages failing every attempt, without it such a message stops the consumer uncommitted
+func WithDeadLetter(topic string) Option {
+	return func(c *Consumer) {
+		c.deadLetterTopic = topic
+	}
+}
+
+type ProducerOption func(*Producer)
+
+func WithProducerDebug(enabled bool) ProducerOption {
+	return func(p *Producer) {
+		p.debug = enabled
+	}
+}
+
+func WithProducerLogger(logger Logger.Logger) ProducerOption {
+	return func(p *Producer) {
+		p.logger = logger
+	}
+}
+
+func WithProducerBrokers(brokers ...string) ProducerOption {
+	return func(p *Producer) {
+		p.brokers = append(p.brokers, brokers...)
+	}
+}
+
+// WithWriterConfig settings of the writers, ex: Dialer for TLS and SASL or RequiredAcks, brokers and topic are set by the producer.
+// The default balancer is kafka.Hash and the default batch timeout is DefaultLinger.
+func WithWriterConfig(config Kafka.WriterConfig) ProducerOption {
+	return func(p *Producer) {
+		p.config = config
+	}
+}
+
+// WithBaggageKeys session baggage keys sent as message headers
+func WithBaggageKeys(keys ...string) ProducerOption {
+	return func(p *Producer) {
+		p.baggageKeys = append(p.baggageKeys, keys...)
+	}
+}
+
+// WithBatch maximum size and wait of the batches of asynchronous messages, default is DefaultBatchSize and DefaultBatchTimeout
+func WithBatch(size int, timeout time.Duration) ProducerOption {
+	return func(p *Producer) {
+		if size > 0 {
+			p.batchSize = size
+		}
+		if timeout > 0 {
+			p.batchTimeout = timeout
+		}
+	}
+}
+
+// WithQueueCapacity asynchronous messages waiting for their batch, default is DefaultQueueCapacity
+func WithQueueCapacity(capacity int) ProducerOption {
+	return func(p *Producer) {
+		if capacity > 0 {
+			p.queueCapacity = capacity
+		}
+	}
+}
+
+// WithErrorCallback receives the asynchronous messages of topic that could not be delivered
+func WithErrorCallback(callback func(topic string, messages []Kafka.Message, err error)) ProducerOption {
+	return func(p *Producer) {
+		p.callback = callback
+	}
+}
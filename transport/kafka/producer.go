@@ -0,0 +1,318 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Session "github.com/agitdevcenter/gopkg/session"
+	"github.com/opentracing/opentracing-go"
+	Kafka "github.com/segmentio/kafka-go"
+)
+
+const (
+	DefaultBatchSize     = 100
+	DefaultBatchTimeout  = 100 * time.Millisecond
+	DefaultQueueCapacity = 1000
+	// DefaultLinger wait of the writers for more messages of the same partition before sending
+	DefaultLinger = 10 * time.Millisecond
+)
+
+var ErrProducerClosed = errors.New("kafka producer closed")
+
+type delivery struct {
+	topic   string
+	message Kafka.Message
+	session *Session.Session
+	start   time.Time
+}
+
+// Producer publishes messages with the thread ID, tracing span and session baggage in their headers.
+// Messages with a key are always published to the same partition.
+type Producer struct {
+	logger        Logger.Logger
+	debug         bool
+	brokers       []string
+	config        Kafka.WriterConfig
+	baggageKeys   []string
+	batchSize     int
+	batchTimeout  time.Duration
+	queueCapacity int
+	callback      func(topic string, messages []Kafka.Message, err error)
+
+	newWriter func(config Kafka.WriterConfig) writer
+
+	mutex        sync.RWMutex
+	closed       bool
+	writersMutex sync.Mutex
+	writers      map[string]writer
+	queue        chan delivery
+	flushed      chan struct{}
+	ready        chan struct{}
+}
+
+// NewProducer starts the delivery of the asynchronous messages, Close or the transport shutdown flushes them
+func NewProducer(opts []ProducerOption) *Producer {
+	p := &Producer{
+		batchSize:     DefaultBatchSize,
+		batchTimeout:  DefaultBatchTimeout,
+		queueCapacity: DefaultQueueCapacity,
+		newWriter: func(config Kafka.WriterConfig) writer {
+			return Kafka.NewWriter(config)
+		},
+		writers: map[string]writer{},
+		flushed: make(chan struct{}),
+		ready:   make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.logger == nil {
+		p.logger = Logger.Noop()
+	}
+
+	p.queue = make(chan delivery, p.queueCapacity)
+	go p.deliver()
+	close(p.ready)
+
+	return p
+}
+
+func (p *Producer) SetLogger(logger Logger.Logger) {
+	p.logger = logger
+}
+
+func (p *Producer) SetDebug(enabled bool) {
+	p.debug = enabled
+}
+
+// Ready is closed once the producer is created
+func (p *Producer) Ready() <-chan struct{} {
+	return p.ready
+}
+
+// Start waits for the transport shutdown then flushes the asynchronous messages and closes the writers
+func (p *Producer) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
+	return func() error {
+		defer wg.Done()
+		<-ctx.Done()
+		return p.Close()
+	}
+}
+
+// Publish writes the messages to topic and waits for their delivery, the session is read from ctx
+func (p *Producer) Publish(ctx context.Context, topic string, messages ...Kafka.Message) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.closed {
+		return ErrProducerClosed
+	}
+
+	session := sessionFrom(ctx)
+	deliveries := make([]delivery, len(messages))
+	for i, message := range messages {
+		deliveries[i] = p.prepare(ctx, session, topic, message)
+	}
+
+	return p.write(ctx, topic, deliveries)
+}
+
+// PublishAsync queues the messages, they are written by batches and delivery errors are given to the callback,
+// see WithErrorCallback. It waits for room in the queue within ctx.
+func (p *Producer) PublishAsync(ctx context.Context, topic string, messages ...Kafka.Message) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.closed {
+		return ErrProducerClosed
+	}
+
+	session := sessionFrom(ctx)
+	for _, message := range messages {
+		select {
+		case p.queue <- p.prepare(ctx, session, topic, message):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close stops accepting messages, delivers the queued ones and closes the writers
+func (p *Producer) Close() error {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		<-p.flushed
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mutex.Unlock()
+
+	<-p.flushed
+
+	p.writersMutex.Lock()
+	defer p.writersMutex.Unlock()
+
+	var err error
+	for topic, w := range p.writers {
+		if closeErr := w.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("error closing kafka writer of %s : %+v", topic, closeErr)
+		}
+	}
+
+	if p.debug {
+		p.logger.Info("kafka producer closed")
+	}
+
+	return err
+}
+
+func sessionFrom(ctx context.Context) *Session.Session {
+	if session, ok := Session.FromContext(ctx); ok {
+		return session
+	}
+	return Session.New(Logger.Noop())
+}
+
+// prepare adds the headers propagated downstream and logs the message
+func (p *Producer) prepare(ctx context.Context, session *Session.Session, topic string, message Kafka.Message) delivery {
+	headers := make(map[string]string)
+	for _, h := range message.Headers {
+		headers[strings.ToLower(h.Key)] = h.Key
+	}
+	add := func(key, value string) {
+		if _, ok := headers[strings.ToLower(key)]; ok || len(value) == 0 {
+			return
+		}
+		headers[strings.ToLower(key)] = key
+		message.Headers = append(message.Headers, Kafka.Header{Key: key, Value: []byte(value)})
+	}
+
+	message.Headers = append([]Kafka.Header{}, message.Headers...)
+	add(HeaderThreadID, session.ThreadID)
+
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		carrier := opentracing.TextMapCarrier{}
+		if err := opentracing.GlobalTracer().Inject(span.Context(), opentracing.TextMap, carrier); err == nil {
+			for key, value := range carrier {
+				add(key, value)
+			}
+		}
+	}
+
+	for _, key := range p.baggageKeys {
+		if value, ok := session.GetBaggage(key); ok {
+			add(key, value)
+		}
+	}
+
+	start := session.T2("Kafka [publish]", topic, string(message.Key), string(message.Value))
+	return delivery{topic: topic, message: message, session: session, start: start}
+}
+
+// write delivers messages of the same topic and logs the result of each one
+func (p *Producer) write(ctx context.Context, topic string, deliveries []delivery) error {
+	messages := make([]Kafka.Message, len(deliveries))
+	for i, d := range deliveries {
+		messages[i] = d.message
+	}
+
+	err := p.writer(topic).WriteMessages(ctx, messages...)
+
+	result := "OK"
+	if err != nil {
+		result = err.Error()
+	}
+	for _, d := range deliveries {
+		d.session.T3(d.start, "Kafka [published]", topic, result)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error publishing %d kafka messages to %s : %+v", len(messages), topic, err)
+	}
+	return nil
+}
+
+// writer of topic, created on its first message
+func (p *Producer) writer(topic string) writer {
+	p.writersMutex.Lock()
+	defer p.writersMutex.Unlock()
+
+	if w, ok := p.writers[topic]; ok {
+		return w
+	}
+
+	config := p.config
+	config.Brokers = p.brokers
+	config.Topic = topic
+	config.Async = false
+	if config.Balancer == nil {
+		config.Balancer = &Kafka.Hash{}
+	}
+	if config.BatchTimeout == 0 {
+		config.BatchTimeout = DefaultLinger
+	}
+
+	w := p.newWriter(config)
+	p.writers[topic] = w
+	return w
+}
+
+// deliver writes the queued messages by batches of each topic until the queue is closed
+func (p *Producer) deliver() {
+	defer close(p.flushed)
+
+	ticker := time.NewTicker(p.batchTimeout)
+	defer ticker.Stop()
+
+	var batch []delivery
+	flush := func() {
+		byTopic := map[string][]delivery{}
+		var topics []string
+		for _, d := range batch {
+			if _, ok := byTopic[d.topic]; !ok {
+				topics = append(topics, d.topic)
+			}
+			byTopic[d.topic] = append(byTopic[d.topic], d)
+		}
+		batch = nil
+
+		for _, topic := range topics {
+			if err := p.write(context.Background(), topic, byTopic[topic]); err != nil {
+				p.logger.Error(err.Error())
+				if p.callback != nil {
+					messages := make([]Kafka.Message, len(byTopic[topic]))
+					for i, d := range byTopic[topic] {
+						messages[i] = d.message
+					}
+					p.callback(topic, messages, err)
+				}
+			}
+		}
+	}
+
+	for {
+		select {
+		case d, ok := <-p.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, d)
+			if len(batch) >= p.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Session "github.com/agitdevcenter/gopkg/session"
+	Kafka "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProducer(writers map[string]*fakeWriter, opts ...ProducerOption) *Producer {
+	p := NewProducer(append([]ProducerOption{WithProducerBrokers("localhost:9092")}, opts...))
+	p.newWriter = func(config Kafka.WriterConfig) writer {
+		return writers[config.Topic]
+	}
+	return p
+}
+
+func TestPublish(t *testing.T) {
+	writers := map[string]*fakeWriter{"payment": {}}
+	p := newTestProducer(writers, WithBaggageKeys("X-Channel"))
+
+	session := Session.New(Logger.Noop()).SetThreadID("thread").SetBaggage("X-Channel", "mobile")
+	ctx := Session.NewContext(context.Background(), session)
+
+	assert.NoError(t, p.Publish(ctx, "payment", Kafka.Message{Key: []byte("account"), Value: []byte("{}")}))
+
+	assert.Len(t, writers["payment"].messages, 1)
+	assert.Equal(t, []Kafka.Header{
+		{Key: HeaderThreadID, Value: []byte("thread")},
+		{Key: "X-Channel", Value: []byte("mobile")},
+	}, writers["payment"].messages[0].Headers)
+
+	assert.NoError(t, p.Close())
+	assert.Equal(t, ErrProducerClosed, p.Publish(ctx, "payment", Kafka.Message{}))
+}
+
+func TestPublishAsync(t *testing.T) {
+	writers := map[string]*fakeWriter{"payment": {}, "refund": {err: errors.New("unavailable")}}
+	var failed []Kafka.Message
+	p := newTestProducer(writers, WithBatch(10, 0), WithErrorCallback(func(topic string, messages []Kafka.Message, err error) {
+		assert.Equal(t, "refund", topic)
+		failed = append(failed, messages...)
+	}))
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, p.PublishAsync(context.Background(), "payment", Kafka.Message{Value: []byte("payment")}))
+	}
+	assert.NoError(t, p.PublishAsync(context.Background(), "refund", Kafka.Message{Value: []byte("refund")}))
+
+	assert.NoError(t, p.Close())
+	assert.Len(t, writers["payment"].messages, 3)
+	assert.Len(t, failed, 1)
+	assert.Equal(t, ErrProducerClosed, p.PublishAsync(context.Background(), "payment", Kafka.Message{}))
+}
@@ -0,0 +1,378 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agitdevcenter/gopkg/health"
+	"github.com/agitdevcenter/gopkg/transport/custom"
+)
+
+const (
+	// HTTP, GRPC and ADMIN names of the servers in dependencies
+	HTTP  = "http"
+	GRPC  = "grpc"
+	ADMIN = "admin"
+)
+
+// Hook runs at a lifecycle stage, an error from a start hook aborts the start
+type Hook func(ctx context.Context) error
+
+type component struct {
+	name         string
+	dependsOn    []string
+	start        func(ctx context.Context, wg *sync.WaitGroup) func() error
+	readiness    interface{}
+	hold         func()
+	startTimeout time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+func (c *component) ready() <-chan struct{} {
+	if readiness, ok := c.readiness.(custom.Readiness); ok {
+		return readiness.Ready()
+	}
+
+	ready := make(chan struct{})
+	close(ready)
+	return ready
+}
+
+// components servers and custom services ordered so dependencies come first
+func (t *Transport) components() ([]*component, error) {
+	var components []*component
+
+	if t.httpServer != nil {
+		components = append(components, &component{name: HTTP, start: t.httpServer.Start, readiness: t.httpServer})
+	}
+
+	if t.grpcServer != nil {
+		components = append(components, &component{name: GRPC, start: t.grpcServer.Start, readiness: t.grpcServer})
+	}
+
+	for i, h := range t.services {
+		if t.inherit {
+			h.Service().SetLogger(t.logger)
+			h.Service().SetDebug(t.debug)
+		}
+
+		name := h.Name()
+		if len(name) == 0 {
+			name = fmt.Sprintf("custom-%d", i)
+		}
+
+		if checker, ok := h.Service().(health.Checker); ok {
+			if err := t.healthRegistry.Register(health.Check{Name: name, Checker: checker, Critical: true}); err != nil {
+				return nil, err
+			}
+		}
+
+		components = append(components, &component{
+			name:         name,
+			dependsOn:    h.DependsOn(),
+			start:        h.Service().Start,
+			readiness:    h.Service(),
+			hold:         h.Hold,
+			startTimeout: h.StartTimeout(),
+		})
+	}
+
+	for _, c := range components {
+		c.dependsOn = append(append([]string{}, c.dependsOn...), t.dependencies[c.name]...)
+	}
+
+	// every component depends on the admin server so the probes and pprof are reachable during start and shutdown
+	if t.admin != nil {
+		t.inheritAdmin()
+		for _, c := range components {
+			c.dependsOn = append(c.dependsOn, ADMIN)
+		}
+		components = append([]*component{{name: ADMIN, dependsOn: t.dependencies[ADMIN], start: t.admin.Start, readiness: t.admin}}, components...)
+	}
+
+	return sortComponents(components)
+}
+
+// sortComponents topological order keeping the declaration order between independent components
+func sortComponents(components []*component) ([]*component, error) {
+	byName := make(map[string]*component, len(components))
+	for _, c := range components {
+		if _, ok := byName[c.name]; ok {
+			return nil, fmt.Errorf("duplicate service name %s", c.name)
+		}
+		byName[c.name] = c
+	}
+
+	for _, c := range components {
+		for _, dependency := range c.dependsOn {
+			if _, ok := byName[dependency]; !ok {
+				return nil, fmt.Errorf("%s depends on unknown service %s", c.name, dependency)
+			}
+		}
+	}
+
+	sorted := make([]*component, 0, len(components))
+	placed := make(map[string]bool, len(components))
+	for len(sorted) < len(components) {
+		progress := false
+		for _, c := range components {
+			if placed[c.name] {
+				continue
+			}
+
+			satisfied := true
+			for _, dependency := range c.dependsOn {
+				if !placed[dependency] {
+					satisfied = false
+					break
+				}
+			}
+
+			if satisfied {
+				sorted = append(sorted, c)
+				placed[c.name] = true
+				progress = true
+			}
+		}
+
+		if !progress {
+			var cycle []string
+			for _, c := range components {
+				if !placed[c.name] {
+					cycle = append(cycle, c.name)
+				}
+			}
+			return nil, fmt.Errorf("circular dependency between %s", strings.Join(cycle, ", "))
+		}
+	}
+
+	return sorted, nil
+}
+
+var errStopped = errors.New("transport stopped")
+
+// waitReady waits for c to be ready within ctx and its own start timeout, errStopped when the transport stops first
+func waitReady(ctx context.Context, c *component, stop <-chan struct{}) error {
+	if c.startTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.startTimeout)
+		defer cancel()
+	}
+
+	select {
+	case <-c.ready():
+		return nil
+	case <-c.done:
+		if c.err != nil {
+			return c.err
+		}
+		return fmt.Errorf("%s stopped before being ready", c.name)
+	case <-ctx.Done():
+		return fmt.Errorf("%s not ready : %+v", c.name, ctx.Err())
+	case <-stop:
+		return errStopped
+	}
+}
+
+func runHooks(ctx context.Context, stage string, hooks []Hook) error {
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			return fmt.Errorf("%s hook error : %+v", stage, err)
+		}
+	}
+	return nil
+}
+
+// lifecycle state of one Run
+type lifecycle struct {
+	transport *Transport
+	ready     chan struct{}
+	stopped   chan struct{}
+	stopOnce  sync.Once
+	mutex     sync.Mutex
+	err       error
+	started   []*component
+}
+
+func newLifecycle(t *Transport) *lifecycle {
+	return &lifecycle{transport: t, ready: make(chan struct{}), stopped: make(chan struct{})}
+}
+
+func (l *lifecycle) stop() {
+	l.stopOnce.Do(func() {
+		close(l.stopped)
+	})
+}
+
+// fail stops the transport, the first error is returned by Run
+func (l *lifecycle) fail(err error) {
+	l.mutex.Lock()
+	if l.err == nil {
+		l.err = err
+	}
+	l.mutex.Unlock()
+	l.stop()
+}
+
+// start returns the error of before start hooks, later failures stop the transport and are returned by shutdown
+func (l *lifecycle) start(components []*component) error {
+	t := l.transport
+
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if t.startupTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.startupTimeout)
+	}
+	defer cancel()
+
+	if err := runHooks(ctx, "before start", t.beforeStart); err != nil {
+		return err
+	}
+
+	err := l.startComponents(ctx, components)
+	if err == nil {
+		for _, c := range l.started {
+			if err = waitReady(ctx, c, l.stopped); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = runHooks(ctx, "after start", t.afterStart)
+	}
+	if err == nil {
+		close(l.ready)
+	} else if err != errStopped {
+		l.fail(err)
+	}
+
+	// stop when every component ended on its own
+	go func(started []*component) {
+		for _, c := range started {
+			<-c.done
+		}
+		l.stop()
+	}(l.started)
+
+	return nil
+}
+
+func (l *lifecycle) startComponents(ctx context.Context, components []*component) error {
+	t := l.transport
+	byName := make(map[string]*component, len(components))
+
+	for _, c := range components {
+		for _, name := range c.dependsOn {
+			if err := waitReady(ctx, byName[name], l.stopped); err != nil {
+				if err == errStopped {
+					return err
+				}
+				return fmt.Errorf("starting %s : %+v", c.name, err)
+			}
+		}
+
+		select {
+		case <-l.stopped:
+			return errStopped
+		default:
+		}
+
+		if c.hold != nil {
+			c.hold()
+		}
+
+		if t.debug {
+			t.logger.Info(fmt.Sprintf("starting %s", c.name))
+		}
+
+		var componentContext context.Context
+		componentContext, c.cancel = context.WithCancel(context.Background())
+		c.done = make(chan struct{})
+
+		// every component has its own wait group so it is stopped independently
+		var wg sync.WaitGroup
+		wg.Add(1)
+		run := c.start(componentContext, &wg)
+
+		go func(c *component) {
+			c.err = run()
+			close(c.done)
+			if c.err != nil {
+				l.fail(fmt.Errorf("server error : %+v", c.err))
+			}
+		}(c)
+
+		byName[c.name] = c
+		l.started = append(l.started, c)
+	}
+
+	return nil
+}
+
+// shutdown marks the health registry not ready, waits for the drain period then stops every component once the components depending on it are stopped, within the shutdown timeout
+func (l *lifecycle) shutdown() error {
+	t := l.transport
+
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if t.shutdownTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.shutdownTimeout)
+	}
+	defer cancel()
+
+	t.healthRegistry.SetReady(false)
+	if t.drainPeriod > 0 {
+		if t.debug {
+			t.logger.Info(fmt.Sprintf("draining for %s", t.drainPeriod))
+		}
+		select {
+		case <-time.After(t.drainPeriod):
+		case <-ctx.Done():
+		}
+	}
+
+	if err := runHooks(ctx, "before stop", t.beforeStop); err != nil {
+		l.fail(err)
+	}
+
+	for _, c := range l.started {
+		go func(c *component) {
+			for _, dependent := range l.started {
+				for _, dependency := range dependent.dependsOn {
+					if dependency == c.name {
+						<-dependent.done
+					}
+				}
+			}
+
+			if t.debug {
+				t.logger.Info(fmt.Sprintf("stopping %s", c.name))
+			}
+			c.cancel()
+		}(c)
+	}
+
+	for _, c := range l.started {
+		select {
+		case <-c.done:
+			if c.err != nil {
+				l.fail(fmt.Errorf("server error : %+v", c.err))
+			}
+		case <-ctx.Done():
+			l.fail(fmt.Errorf("shutdown deadline exceeded waiting for %s", c.name))
+		}
+	}
+
+	if err := runHooks(ctx, "after stop", t.afterStop); err != nil {
+		l.fail(err)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.err
+}
@@ -0,0 +1,203 @@
+package transport
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agitdevcenter/gopkg/health"
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/agitdevcenter/gopkg/transport/custom"
+	"github.com/stretchr/testify/assert"
+)
+
+func names(components []*component) (result []string) {
+	for _, c := range components {
+		result = append(result, c.name)
+	}
+	return
+}
+
+func TestSortComponents(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []*component
+		expected   []string
+		err        string
+	}{
+		{
+			name:       "declaration order",
+			components: []*component{{name: "a"}, {name: "b"}, {name: "c"}},
+			expected:   []string{"a", "b", "c"},
+		},
+		{
+			name: "dependencies first",
+			components: []*component{
+				{name: HTTP, dependsOn: []string{"cache"}},
+				{name: "cache", dependsOn: []string{"db"}},
+				{name: "worker", dependsOn: []string{"db"}},
+				{name: "db"},
+			},
+			expected: []string{"db", "cache", "worker", HTTP},
+		},
+		{
+			name:       "unknown dependency",
+			components: []*component{{name: HTTP, dependsOn: []string{"db"}}},
+			err:        "http depends on unknown service db",
+		},
+		{
+			name:       "duplicate name",
+			components: []*component{{name: "db"}, {name: "db"}},
+			err:        "duplicate service name db",
+		},
+		{
+			name: "cycle",
+			components: []*component{
+				{name: "db"},
+				{name: "a", dependsOn: []string{"b"}},
+				{name: "b", dependsOn: []string{"c"}},
+				{name: "c", dependsOn: []string{"a", "db"}},
+			},
+			err: "circular dependency between a, b, c",
+		},
+		{
+			name:       "self dependency",
+			components: []*component{{name: "a", dependsOn: []string{"a"}}},
+			err:        "circular dependency between a",
+		},
+	}
+
+	for _, test := range tests {
+		sorted, err := sortComponents(test.components)
+		if len(test.err) > 0 {
+			assert.EqualError(t, err, test.err, test.name)
+			continue
+		}
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, names(sorted), test.name)
+	}
+}
+
+// events ordered record of the services starting and stopping
+type events struct {
+	mutex sync.Mutex
+	list  []string
+}
+
+func (e *events) add(event string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.list = append(e.list, event)
+}
+
+func (e *events) index(event string) int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for i, recorded := range e.list {
+		if recorded == event {
+			return i
+		}
+	}
+	return -1
+}
+
+// fakeService runs until its context is canceled, a hung service ignores it
+type fakeService struct {
+	name    string
+	events  *events
+	hung    bool
+	release chan struct{}
+}
+
+func (s *fakeService) SetDebug(enabled bool) {}
+
+func (s *fakeService) SetLogger(logger Logger.Logger) {}
+
+func (s *fakeService) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
+	s.events.add("start " + s.name)
+	return func() error {
+		defer wg.Done()
+		if s.hung {
+			<-s.release
+		} else {
+			<-ctx.Done()
+		}
+		s.events.add("stop " + s.name)
+		return nil
+	}
+}
+
+// runLifecycle starts the services of opts then shuts them down once ready
+func runLifecycle(t *testing.T, opts []Option) error {
+	transport := New(append(opts, WithHealthRegistry(health.NewRegistry())))
+	components, err := transport.components()
+	if !assert.NoError(t, err) {
+		return err
+	}
+
+	l := newLifecycle(transport)
+	assert.NoError(t, l.start(components))
+	select {
+	case <-l.ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("services not ready")
+	}
+
+	l.stop()
+	return l.shutdown()
+}
+
+func TestLifecycleStopOrder(t *testing.T) {
+	recorded := &events{}
+	service := func(name string, dependsOn ...string) Option {
+		return WithCustom(custom.New(
+			custom.OptionName(name),
+			custom.OptionDependsOn(dependsOn...),
+			custom.OptionService(&fakeService{name: name, events: recorded}),
+		))
+	}
+
+	err := runLifecycle(t, []Option{
+		service("api", "cache"),
+		service("cache", "db"),
+		service("worker", "db"),
+		service("db"),
+		WithShutdownTimeout(5 * time.Second),
+	})
+	assert.NoError(t, err)
+
+	for _, order := range [][2]string{
+		{"start db", "start cache"},
+		{"start cache", "start api"},
+		{"start db", "start worker"},
+		{"stop api", "stop cache"},
+		{"stop cache", "stop db"},
+		{"stop worker", "stop db"},
+	} {
+		first, then := recorded.index(order[0]), recorded.index(order[1])
+		assert.True(t, first >= 0 && then >= 0 && first < then, "%s before %s : %v", order[0], order[1], recorded.list)
+	}
+}
+
+func TestLifecycleShutdownTimeout(t *testing.T) {
+	recorded := &events{}
+	hung := &fakeService{name: "hung", events: recorded, hung: true, release: make(chan struct{})}
+	defer close(hung.release)
+
+	start := time.Now()
+	err := runLifecycle(t, []Option{
+		WithCustom(custom.New(custom.OptionName("db"), custom.OptionService(&fakeService{name: "db", events: recorded}))),
+		WithCustom(custom.New(custom.OptionName("hung"), custom.OptionService(hung))),
+		WithShutdownTimeout(100 * time.Millisecond),
+	})
+
+	// the hung service is abandoned at the deadline, the others are stopped
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "shutdown deadline exceeded waiting for hung"), err.Error())
+	}
+	assert.True(t, time.Since(start) < 2*time.Second)
+	assert.True(t, recorded.index("stop db") >= 0)
+	assert.Equal(t, -1, recorded.index("stop hung"))
+}
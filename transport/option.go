@@ -1,7 +1,11 @@
 package transport
 
 import (
+	"time"
+
+	"github.com/agitdevcenter/gopkg/health"
 	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/agitdevcenter/gopkg/transport/admin"
 	"github.com/agitdevcenter/gopkg/transport/custom"
 	"github.com/agitdevcenter/gopkg/transport/grpc"
 	"github.com/agitdevcenter/gopkg/transport/http"
@@ -39,8 +43,96 @@ func WithGRPCServer(grpcServer *grpc.Server) Option {
 	}
 }
 
+// WithAdmin admin server of the operational endpoints, started first and stopped last
+func WithAdmin(admin *admin.Admin) Option {
+	return func(t *Transport) {
+		t.admin = admin
+	}
+}
+
 func WithCustom(service *custom.Holder) Option {
 	return func(t *Transport) {
 		t.services = append(t.services, service)
 	}
 }
+
+// WithDependency starts name after the servers and services it depends on and stops it before them,
+// use HTTP, GRPC or custom.OptionName names
+func WithDependency(name string, dependsOn ...string) Option {
+	return func(t *Transport) {
+		t.dependencies[name] = append(t.dependencies[name], dependsOn...)
+	}
+}
+
+// WithStartupTimeout maximum duration for every server and service to be ready
+func WithStartupTimeout(startupTimeout time.Duration) Option {
+	return func(t *Transport) {
+		t.startupTimeout = startupTimeout
+	}
+}
+
+// WithShutdownTimeout deadline for the whole transport to stop, including stop hooks
+func WithShutdownTimeout(shutdownTimeout time.Duration) Option {
+	return func(t *Transport) {
+		t.shutdownTimeout = shutdownTimeout
+	}
+}
+
+// WithDrainPeriod on shutdown the health registry is not ready (readiness URL and gRPC health are failing)
+// during drainPeriod before the servers stop, so load balancers stop routing new requests first
+func WithDrainPeriod(drainPeriod time.Duration) Option {
+	return func(t *Transport) {
+		t.drainPeriod = drainPeriod
+	}
+}
+
+func WithBeforeStart(hooks ...Hook) Option {
+	return func(t *Transport) {
+		t.beforeStart = append(t.beforeStart, hooks...)
+	}
+}
+
+// WithAfterStart hooks run once every server and service is ready
+func WithAfterStart(hooks ...Hook) Option {
+	return func(t *Transport) {
+		t.afterStart = append(t.afterStart, hooks...)
+	}
+}
+
+func WithBeforeStop(hooks ...Hook) Option {
+	return func(t *Transport) {
+		t.beforeStop = append(t.beforeStop, hooks...)
+	}
+}
+
+func WithAfterStop(hooks ...Hook) Option {
+	return func(t *Transport) {
+		t.afterStop = append(t.afterStop, hooks...)
+	}
+}
+
+// WithReload hooks run after the servers and services are reloaded on SIGHUP
+func WithReload(hooks ...Hook) Option {
+	return func(t *Transport) {
+		t.reload = append(t.reload, hooks...)
+	}
+}
+
+// WithGracefulRestart on RestartSignal (SIGUSR2) the binary is started again with the HTTP, gRPC and admin listeners,
+// this process drains and stops once the new one is ready within readyTimeout, default is DefaultRestartTimeout
+func WithGracefulRestart(enabled bool, readyTimeout time.Duration) Option {
+	return func(t *Transport) {
+		t.gracefulRestart = enabled
+		t.restartTimeout = readyTimeout
+	}
+}
+
+// WithHealthRegistry registry of the custom services implementing health.Checker instead of health.DefaultRegistry,
+// with inherit the servers and the admin server serve it
+func WithHealthRegistry(registry *health.Registry) Option {
+	return func(t *Transport) {
+		if registry != nil {
+			t.healthRegistry = registry
+		}
+	}
+}
@@ -0,0 +1,204 @@
+//go:build !windows
+// +build !windows
+
+package transport
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// ListenersEnv names of the listeners passed by the parent process, their file descriptors start at 3
+	ListenersEnv = "GOPKG_LISTENERS"
+	// ReadyEnv file descriptor written by the child process once it is ready
+	ReadyEnv = "GOPKG_READY_FD"
+
+	DefaultRestartTimeout = 30 * time.Second
+)
+
+// RestartSignal starts a graceful restart when it is enabled, see WithGracefulRestart
+var RestartSignal os.Signal = syscall.SIGUSR2
+
+type fileListener interface {
+	File() (*os.File, error)
+}
+
+// restart execs the same binary with the listeners of the servers and waits for it to be ready,
+// the caller stops this process on success
+func (t *Transport) restart() (err error) {
+	var names []string
+	var files []*os.File
+	var sockets []*net.UnixListener
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+		// this process keeps serving, its socket files are removed on close again
+		if err != nil {
+			for _, socket := range sockets {
+				socket.SetUnlinkOnClose(true)
+			}
+		}
+	}()
+
+	listeners := map[string]net.Listener{}
+	if t.httpServer != nil {
+		listeners[HTTP] = t.httpServer.Listener()
+	}
+	if t.grpcServer != nil {
+		listeners[GRPC] = t.grpcServer.Listener()
+	}
+	if t.admin != nil {
+		listeners[ADMIN] = t.admin.Listener()
+	}
+
+	for _, name := range []string{HTTP, GRPC, ADMIN} {
+		listener, ok := listeners[name].(fileListener)
+		if !ok {
+			continue
+		}
+
+		// the socket file is served by the new process, closing this listener must not remove it
+		if socket, ok := listener.(*net.UnixListener); ok {
+			socket.SetUnlinkOnClose(false)
+			sockets = append(sockets, socket)
+		}
+
+		file, err := listener.File()
+		if err != nil {
+			return fmt.Errorf("passing %s listener : %+v", name, err)
+		}
+		names = append(names, name)
+		files = append(files, file)
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("creating ready pipe : %+v", err)
+	}
+	defer readyReader.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		readyWriter.Close()
+		return fmt.Errorf("finding executable : %+v", err)
+	}
+
+	var environment []string
+	for _, variable := range os.Environ() {
+		if !strings.HasPrefix(variable, ListenersEnv+"=") && !strings.HasPrefix(variable, ReadyEnv+"=") {
+			environment = append(environment, variable)
+		}
+	}
+
+	command := exec.Command(executable, os.Args[1:]...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	command.ExtraFiles = append(files, readyWriter)
+	command.Env = append(environment,
+		fmt.Sprintf("%s=%s", ListenersEnv, strings.Join(names, ",")),
+		fmt.Sprintf("%s=%d", ReadyEnv, 3+len(files)),
+	)
+
+	err = command.Start()
+	// only the child holds the write end now, reading gets EOF when it exits before being ready
+	readyWriter.Close()
+	if err != nil {
+		return fmt.Errorf("starting child process : %+v", err)
+	}
+
+	if t.debug {
+		t.logger.Info(fmt.Sprintf("graceful restart, waiting for child process %d", command.Process.Pid))
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		if _, err := readyReader.Read(make([]byte, 1)); err != nil {
+			ready <- fmt.Errorf("child process %d exited before being ready", command.Process.Pid)
+			return
+		}
+		ready <- nil
+	}()
+
+	timeout := t.restartTimeout
+	if timeout <= 0 {
+		timeout = DefaultRestartTimeout
+	}
+
+	select {
+	case err = <-ready:
+	case <-time.After(timeout):
+		command.Process.Kill()
+		err = fmt.Errorf("child process %d not ready after %s", command.Process.Pid, timeout)
+	}
+
+	if err != nil {
+		go command.Wait()
+		return err
+	}
+
+	return command.Process.Release()
+}
+
+// inheritListeners sets the listeners passed by the parent process on the servers
+func (t *Transport) inheritListeners() error {
+	names := os.Getenv(ListenersEnv)
+	if len(names) == 0 {
+		return nil
+	}
+	os.Unsetenv(ListenersEnv)
+
+	for i, name := range strings.Split(names, ",") {
+		file := os.NewFile(uintptr(3+i), name)
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("inheriting %s listener : %+v", name, err)
+		}
+
+		switch {
+		case name == HTTP && t.httpServer != nil:
+			t.httpServer.SetListener(listener)
+		case name == GRPC && t.grpcServer != nil:
+			t.grpcServer.SetListener(listener)
+		case name == ADMIN && t.admin != nil:
+			t.admin.SetListener(listener)
+		default:
+			listener.Close()
+		}
+
+		if t.debug {
+			t.logger.Info(fmt.Sprintf("%s listener inherited on %s", name, listener.Addr()))
+		}
+	}
+
+	return nil
+}
+
+// notifyParent tells the parent process of a graceful restart that this process is ready
+func notifyParent() error {
+	fd := os.Getenv(ReadyEnv)
+	if len(fd) == 0 {
+		return nil
+	}
+	os.Unsetenv(ReadyEnv)
+
+	number, err := strconv.Atoi(fd)
+	if err != nil {
+		return fmt.Errorf("invalid %s %s", ReadyEnv, fd)
+	}
+
+	file := os.NewFile(uintptr(number), "ready")
+	defer file.Close()
+
+	_, err = file.Write([]byte{1})
+	return err
+}
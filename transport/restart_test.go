@@ -0,0 +1,115 @@
+//go:build !windows
+// +build !windows
+
+package transport
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/agitdevcenter/gopkg/transport/http"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// socketEnv path of the unix socket shared by the test and the process it restarts into
+const socketEnv = "GOPKG_TEST_SOCKET"
+
+type pidRouter struct{}
+
+func (pidRouter) Route(e *echo.Echo) {
+	e.GET("/pid", func(c echo.Context) error {
+		return c.String(nethttp.StatusOK, strconv.Itoa(os.Getpid()))
+	})
+}
+
+func unixClient(socket string) *nethttp.Client {
+	return &nethttp.Client{Transport: &nethttp.Transport{
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+}
+
+func pid(socket string) (int, error) {
+	response, err := unixClient(socket).Get("http://unix/pid")
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(body))
+}
+
+// TestRestartUnixSocket the socket file is kept when the process restarted from stops, the test binary
+// is executed again as the new process
+func TestRestartUnixSocket(t *testing.T) {
+	if len(os.Getenv(ReadyEnv)) > 0 {
+		// the new process serves the inherited socket until it is stopped by the test
+		socket := os.Getenv(socketEnv)
+		transport := New([]Option{WithHTTPServer(http.New([]http.Option{http.WithUnixSocket(socket), http.WithRouter(pidRouter{})}))})
+		assert.NoError(t, transport.Run())
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "restart")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "http.sock")
+	os.Setenv(socketEnv, socket)
+	defer os.Unsetenv(socketEnv)
+
+	transport := New([]Option{
+		WithHTTPServer(http.New([]http.Option{http.WithUnixSocket(socket), http.WithRouter(pidRouter{})})),
+		WithGracefulRestart(true, 30*time.Second),
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- transport.Run()
+	}()
+
+	assert.Eventually(t, func() bool {
+		served, err := pid(socket)
+		return err == nil && served == os.Getpid()
+	}, 10*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, syscall.Kill(os.Getpid(), RestartSignal.(syscall.Signal)))
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("process not stopped after the graceful restart")
+	}
+
+	// stopping this process left the socket of the new process in place
+	_, err = os.Stat(socket)
+	assert.NoError(t, err)
+
+	child, err := pid(socket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEqual(t, os.Getpid(), child)
+
+	assert.NoError(t, syscall.Kill(child, syscall.SIGTERM))
+	assert.Eventually(t, func() bool {
+		_, err := pid(socket)
+		return err != nil
+	}, 10*time.Second, 10*time.Millisecond)
+}
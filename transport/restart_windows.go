@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package transport
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+const DefaultRestartTimeout = 30 * time.Second
+
+// RestartSignal nil, graceful restart is not supported on windows
+var RestartSignal os.Signal
+
+func (t *Transport) restart() error {
+	return errors.New("graceful restart is not supported on windows")
+}
+
+func (t *Transport) inheritListeners() error {
+	return nil
+}
+
+func notifyParent() error {
+	return nil
+}
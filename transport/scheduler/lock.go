@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/agitdevcenter/gopkg/cache"
+	"github.com/agitdevcenter/gopkg/utils"
+	"github.com/hashicorp/consul/api"
+)
+
+// TTL bounds of the Consul sessions
+const (
+	consulMinimumTTL = 10 * time.Second
+	consulMaximumTTL = 24 * time.Hour
+)
+
+// Locker grants a key to a single replica until ttl, the key is made of the job name and its run time
+type Locker interface {
+	Lock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+type keyval struct {
+	keyval cache.Keyval
+}
+
+// NewKeyval lock stored in redis or memcached, the key expires after ttl
+func NewKeyval(kv cache.Keyval) Locker {
+	return &keyval{keyval: kv}
+}
+
+// Lock adds the key then reads it back, the redis Add does not fail when the key exists
+func (k *keyval) Lock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+
+	token := []byte(utils.GenerateThreadId())
+	k.keyval.Add(key, token, ttl)
+
+	value, err := k.keyval.Get(key)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(value, token), nil
+}
+
+type consul struct {
+	client *api.Client
+}
+
+// NewConsul lock acquired with a Consul session, the key is deleted when the session expires after ttl
+func NewConsul(client *api.Client) Locker {
+	return &consul{client: client}
+}
+
+func (c *consul) Lock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if ttl < consulMinimumTTL {
+		ttl = consulMinimumTTL
+	}
+	if ttl > consulMaximumTTL {
+		ttl = consulMaximumTTL
+	}
+
+	options := (&api.WriteOptions{}).WithContext(ctx)
+	session, _, err := c.client.Session().Create(&api.SessionEntry{
+		Name:     key,
+		TTL:      ttl.String(),
+		Behavior: api.SessionBehaviorDelete,
+	}, options)
+	if err != nil {
+		return false, fmt.Errorf("error creating consul session : %+v", err)
+	}
+
+	acquired, _, err := c.client.KV().Acquire(&api.KVPair{Key: key, Session: session}, options)
+	if err != nil || !acquired {
+		c.client.Session().Destroy(session, options)
+	}
+	return acquired, err
+}
@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"time"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+)
+
+type Option func(*Scheduler)
+
+func WithDebug(enabled bool) Option {
+	return func(s *Scheduler) {
+		s.debug = enabled
+	}
+}
+
+func WithLogger(logger Logger.Logger) Option {
+	return func(s *Scheduler) {
+		s.logger = logger
+	}
+}
+
+// WithSession application name and version of the TDR
+func WithSession(name, version string) Option {
+	return func(s *Scheduler) {
+		s.name = name
+		s.version = version
+	}
+}
+
+// WithLocation time zone of the cron expressions, default is time.Local
+func WithLocation(location *time.Location) Option {
+	return func(s *Scheduler) {
+		if location != nil {
+			s.location = location
+		}
+	}
+}
+
+// WithLocker runs each job once across the replicas, ex: NewKeyval or NewConsul
+func WithLocker(locker Locker) Option {
+	return func(s *Scheduler) {
+		s.locker = locker
+	}
+}
+
+// WithLockPrefix prefix of the lock keys, default is DefaultLockPrefix
+func WithLockPrefix(prefix string) Option {
+	return func(s *Scheduler) {
+		s.lockPrefix = prefix
+	}
+}
+
+func WithJobs(jobs ...Job) Option {
+	return func(s *Scheduler) {
+		s.jobs = append(s.jobs, jobs...)
+	}
+}
@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next run after t
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+type every time.Duration
+
+// Every runs at each multiple of interval aligned on UTC, ex: every hour at minute 0, so the replicas share the same run times
+func Every(interval time.Duration) Schedule {
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return every(interval)
+}
+
+func (e every) Next(t time.Time) time.Time {
+	interval := time.Duration(e)
+	return t.Truncate(interval).Add(interval)
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minute = field{name: "minute", min: 0, max: 59}
+	hour   = field{name: "hour", min: 0, max: 23}
+	dom    = field{name: "day of month", min: 1, max: 31}
+	month  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dow = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cron bits of the matching values of each field
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	anyDom, anyDow                bool
+}
+
+// Cron parses a standard cron expression, minute hour day-of-month month day-of-week, in the location of the scheduler.
+// Fields accept *, lists, ranges, steps and the names of months and days, descriptors @yearly, @monthly, @weekly,
+// @daily, @hourly and @every <duration> are accepted too.
+func Cron(expression string) (Schedule, error) {
+	expression = strings.TrimSpace(expression)
+
+	if strings.HasPrefix(expression, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expression, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %s : %+v", expression, err)
+		}
+		return Every(interval), nil
+	}
+	if descriptor, ok := descriptors[strings.ToLower(expression)]; ok {
+		expression = descriptor
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %s : expected 5 fields", expression)
+	}
+
+	var c cron
+	var err error
+	bits := []*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, f := range []field{minute, hour, dom, month, dow} {
+		if *bits[i], err = f.parse(fields[i]); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %s : %+v", expression, err)
+		}
+	}
+
+	// sunday is 0 or 7
+	if c.dow&(1<<7) > 0 {
+		c.dow |= 1
+	}
+	c.anyDom = fields[2] == "*" || fields[2] == "?"
+	c.anyDow = fields[4] == "*" || fields[4] == "?"
+
+	return &c, nil
+}
+
+// MustCron is Cron panicking on an invalid expression, ex: for constant expressions
+func MustCron(expression string) Schedule {
+	schedule, err := Cron(expression)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+func (f field) parse(expression string) (bits uint64, err error) {
+	for _, part := range strings.Split(expression, ",") {
+		step, stepped := 1, false
+		if i := strings.Index(part, "/"); i >= 0 {
+			stepped = true
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %s of %s", part[i+1:], f.name)
+			}
+			part = part[:i]
+		}
+
+		start, end := f.min, f.max
+		switch {
+		case part == "*" || part == "?":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			if start, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+		default:
+			if start, err = f.value(part); err != nil {
+				return 0, err
+			}
+			// a single value with a step runs until the maximum
+			if !stepped {
+				end = start
+			}
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("invalid range %s of %s", part, f.name)
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(expression string) (int, error) {
+	if value, ok := f.names[strings.ToLower(expression)]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(expression)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid value %s of %s", expression, f.name)
+	}
+	return value, nil
+}
+
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// no match within 5 years, ex: 30 february
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.day(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// day matches the day of month or the day of week when both are restricted, like cron
+func (c *cron) day(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) > 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) > 0
+	if c.anyDom || c.anyDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,288 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	Error "github.com/agitdevcenter/gopkg/error"
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Response "github.com/agitdevcenter/gopkg/response"
+	Session "github.com/agitdevcenter/gopkg/session"
+	"github.com/agitdevcenter/gopkg/utils"
+)
+
+const (
+	Name              = "LinkAja"
+	Version           = "1.0.0"
+	DefaultLockPrefix = "scheduler"
+	// MaximumLockTTL of the run locks of the jobs running less often, each run is locked with its own key
+	MaximumLockTTL = 24 * time.Hour
+)
+
+// Overlap policy of a run scheduled while the previous run of the job is still running
+type Overlap int
+
+const (
+	// OverlapSkip skips the run, the default
+	OverlapSkip Overlap = iota
+	// OverlapAllow runs at the same time as the previous run
+	OverlapAllow
+	// OverlapWait runs once the previous run is done, the runs missed meanwhile are merged into one
+	OverlapWait
+)
+
+// Job run on its schedule, the session of the run is also in ctx, see session.FromContext, ctx is canceled on shutdown
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context, session *Session.Session) error
+	// Timeout cancels the context of the run, zero is no timeout
+	Timeout time.Duration
+	Overlap Overlap
+}
+
+// Scheduler custom.Service running jobs on cron expressions or intervals, once across the replicas with a Locker
+type Scheduler struct {
+	logger     Logger.Logger
+	debug      bool
+	name       string
+	version    string
+	location   *time.Location
+	locker     Locker
+	lockPrefix string
+	jobs       []Job
+
+	now       func() time.Time
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+func New(opts []Option) *Scheduler {
+	s := &Scheduler{
+		name:       Name,
+		version:    Version,
+		location:   time.Local,
+		lockPrefix: DefaultLockPrefix,
+		now:        time.Now,
+		ready:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.logger == nil {
+		s.logger = Logger.Noop()
+	}
+
+	return s
+}
+
+func (s *Scheduler) SetLogger(logger Logger.Logger) {
+	s.logger = logger
+}
+
+func (s *Scheduler) SetDebug(enabled bool) {
+	s.debug = enabled
+}
+
+// Ready is closed once the jobs are scheduled
+func (s *Scheduler) Ready() <-chan struct{} {
+	return s.ready
+}
+
+func (s *Scheduler) validate() error {
+	names := make(map[string]bool, len(s.jobs))
+	for _, job := range s.jobs {
+		switch {
+		case len(job.Name) == 0:
+			return errors.New("scheduler job without name")
+		case names[job.Name]:
+			return fmt.Errorf("duplicate scheduler job %s", job.Name)
+		case job.Schedule == nil:
+			return fmt.Errorf("scheduler job %s without schedule", job.Name)
+		case job.Run == nil:
+			return fmt.Errorf("scheduler job %s without run", job.Name)
+		}
+		names[job.Name] = true
+	}
+	return nil
+}
+
+// Start schedules the jobs until ctx is done then waits for the runs in progress, their context is canceled too
+func (s *Scheduler) Start(ctx context.Context, wg *sync.WaitGroup) func() error {
+	return func() error {
+		defer wg.Done()
+
+		if err := s.validate(); err != nil {
+			return err
+		}
+
+		var running sync.WaitGroup
+		for _, job := range s.jobs {
+			running.Add(1)
+			go func(job Job) {
+				defer running.Done()
+				s.loop(ctx, job, &running)
+			}(job)
+		}
+
+		if s.debug {
+			s.logger.Info(fmt.Sprintf("scheduler started with %d jobs", len(s.jobs)))
+		}
+		s.readyOnce.Do(func() {
+			close(s.ready)
+		})
+
+		<-ctx.Done()
+		running.Wait()
+
+		if s.debug {
+			s.logger.Info("scheduler stopped")
+		}
+
+		return nil
+	}
+}
+
+// loop waits for each run time of job and starts the run according to its overlap policy
+func (s *Scheduler) loop(ctx context.Context, job Job, running *sync.WaitGroup) {
+	busy := make(chan struct{}, 1)
+
+	next := job.Schedule.Next(s.now().In(s.location))
+	for {
+		if next.IsZero() {
+			s.logger.Error(fmt.Sprintf("scheduler job %s has no next run", job.Name))
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(s.now()))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		scheduled := next
+		next = job.Schedule.Next(scheduled)
+
+		switch job.Overlap {
+		case OverlapAllow:
+			running.Add(1)
+			go func() {
+				defer running.Done()
+				s.run(ctx, job, scheduled)
+			}()
+		case OverlapWait:
+			s.run(ctx, job, scheduled)
+			next = s.latest(job, next)
+		default:
+			select {
+			case busy <- struct{}{}:
+				running.Add(1)
+				go func() {
+					defer func() {
+						<-busy
+						running.Done()
+					}()
+					s.run(ctx, job, scheduled)
+				}()
+			default:
+				s.logger.Info(fmt.Sprintf("scheduler job %s at %s skipped, the previous run is not done", job.Name, scheduled.Format(time.RFC3339)))
+			}
+		}
+	}
+}
+
+// latest run time of job from next until now, the same on every replica
+func (s *Scheduler) latest(job Job, next time.Time) time.Time {
+	now := s.now()
+	for !next.IsZero() && next.Before(now) {
+		following := job.Schedule.Next(next)
+		if following.IsZero() || following.After(now) {
+			break
+		}
+		next = following
+	}
+	return next
+}
+
+// run the job once the lock of its run time is acquired, with its own session and TDR
+func (s *Scheduler) run(ctx context.Context, job Job, scheduled time.Time) {
+	if s.locker != nil {
+		key := fmt.Sprintf("%s/%s/%d", s.lockPrefix, job.Name, scheduled.Unix())
+		acquired, err := s.locker.Lock(ctx, key, lockTTL(job, scheduled))
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("error locking scheduler job %s : %+v", job.Name, err))
+			return
+		}
+		if !acquired {
+			if s.debug {
+				s.logger.Info(fmt.Sprintf("scheduler job %s at %s run by another replica", job.Name, scheduled.Format(time.RFC3339)))
+			}
+			return
+		}
+	}
+
+	session := Session.New(s.logger).
+		SetAppName(s.name).
+		SetAppVersion(s.version).
+		SetURL(job.Name).
+		SetMethod("Scheduler").
+		SetThreadID(utils.GenerateThreadId())
+	session.Put("scheduled", scheduled.Format(time.RFC3339))
+	session.T1("Job Started")
+
+	ctx = Session.NewContext(ctx, session)
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	end(session, execute(ctx, job, session))
+}
+
+// lockTTL until the next run time, the lock outlives the replicas starting the same run late, at most MaximumLockTTL
+func lockTTL(job Job, scheduled time.Time) time.Duration {
+	ttl := job.Timeout
+	if next := job.Schedule.Next(scheduled); !next.IsZero() {
+		ttl = next.Sub(scheduled)
+	}
+	if ttl > MaximumLockTTL {
+		ttl = MaximumLockTTL
+	}
+	return ttl
+}
+
+// execute runs the job, a panic is returned as an error
+func execute(ctx context.Context, job Job, session *Session.Session) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("scheduler job panic : %+v", r)
+		}
+	}()
+	return job.Run(ctx, session)
+}
+
+// end writes the TDR of the run, the response code is the one of the application error
+func end(session *Session.Session, err error) {
+	if err == nil {
+		session.SetResponseCode(Response.SuccessCode)
+		session.T4(Response.CreateResponse(Response.SuccessCode, "OK", struct{}{}))
+		return
+	}
+
+	code := Response.GeneralError
+	if he, ok := Error.As(err); ok {
+		code = he.ErrorCode
+	}
+
+	session.SetErrorMessage(err.Error())
+	session.SetResponseCode(code)
+	session.T4(Response.CreateResponse(code, err.Error(), struct{}{}))
+}
@@ -0,0 +1,299 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Session "github.com/agitdevcenter/gopkg/session"
+	"github.com/stretchr/testify/assert"
+)
+
+// memory keyval of the replicas, Add does not fail when the key exists like redis
+type memory struct {
+	mutex  sync.Mutex
+	values map[string][]byte
+}
+
+func (m *memory) SetLogger(l Logger.Logger) {}
+
+func (m *memory) Add(key string, val []byte, expiration time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.values[key]; !ok {
+		m.values[key] = val
+	}
+	return nil
+}
+
+func (m *memory) Set(key string, val []byte, expiration time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.values[key] = val
+	return nil
+}
+
+func (m *memory) Delete(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memory) Get(key string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.values[key], nil
+}
+
+func (m *memory) Incr(key string) ([]byte, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestCron(t *testing.T) {
+	from := time.Date(2020, 3, 1, 22, 30, 15, 0, time.UTC) // sunday
+
+	tests := []struct {
+		expression string
+		next       time.Time
+	}{
+		{"* * * * *", time.Date(2020, 3, 1, 22, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, 3, 1, 22, 45, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2020, 3, 2, 2, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * mon-fri", time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 8 15 * 6", time.Date(2020, 3, 7, 8, 30, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2020, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"@every 1h", time.Date(2020, 3, 1, 23, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		schedule, err := Cron(test.expression)
+		assert.NoError(t, err, test.expression)
+		assert.Equal(t, test.next, schedule.Next(from), test.expression)
+	}
+
+	for _, expression := range []string{"* * * *", "60 * * * *", "5-1 * * * *", "* * * foo *", "*/0 * * * *", "@every soon"} {
+		_, err := Cron(expression)
+		assert.Error(t, err, expression)
+	}
+
+	assert.True(t, MustCron("0 0 30 2 *").Next(from).IsZero())
+}
+
+func TestOverlapWait(t *testing.T) {
+	now := time.Date(2020, 3, 1, 22, 30, 15, 0, time.UTC)
+	s := New(nil)
+	s.now = func() time.Time { return now }
+
+	job := Job{Schedule: Every(time.Minute)}
+	assert.Equal(t, time.Date(2020, 3, 1, 22, 30, 0, 0, time.UTC), s.latest(job, time.Date(2020, 3, 1, 22, 27, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2020, 3, 1, 22, 31, 0, 0, time.UTC), s.latest(job, time.Date(2020, 3, 1, 22, 31, 0, 0, time.UTC)))
+}
+
+func TestLocker(t *testing.T) {
+	kv := &memory{values: map[string][]byte{}}
+	var mutex sync.Mutex
+	runs := map[string]int{}
+	job := Job{
+		Name:     "settlement",
+		Schedule: Every(time.Second),
+		Run: func(ctx context.Context, session *Session.Session) error {
+			scheduled, err := session.Get("scheduled")
+			assert.NoError(t, err)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			runs[scheduled.(string)]++
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var replicas sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		s := New([]Option{WithLocker(NewKeyval(kv)), WithJobs(job)})
+		replicas.Add(1)
+		go func() {
+			defer replicas.Done()
+			var wg sync.WaitGroup
+			wg.Add(1)
+			assert.NoError(t, s.Start(ctx, &wg)())
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(runs) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	replicas.Wait()
+
+	for scheduled, count := range runs {
+		assert.Equal(t, 1, count, scheduled)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	run := func(ctx context.Context, session *Session.Session) error { return nil }
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s := New([]Option{WithJobs(Job{Name: "reminder", Schedule: Every(time.Minute), Run: run}, Job{Name: "reminder", Schedule: Every(time.Minute), Run: run})})
+	assert.Error(t, s.Start(context.Background(), &wg)())
+}
+
+// tick schedule shorter than the second of Every
+type tick time.Duration
+
+func (d tick) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(d)).Add(time.Duration(d))
+}
+
+// recorder of the runs of a job, the longest run blocks until release
+type recorder struct {
+	mutex       sync.Mutex
+	runs        int
+	active      int
+	concurrency int
+}
+
+func (r *recorder) run(release <-chan struct{}) func(ctx context.Context, session *Session.Session) error {
+	return func(ctx context.Context, session *Session.Session) error {
+		r.mutex.Lock()
+		r.runs++
+		r.active++
+		if r.active > r.concurrency {
+			r.concurrency = r.active
+		}
+		r.mutex.Unlock()
+
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+
+		r.mutex.Lock()
+		r.active--
+		r.mutex.Unlock()
+		return nil
+	}
+}
+
+func (r *recorder) count() (runs, concurrency int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.runs, r.concurrency
+}
+
+// start runs the scheduler until the returned stop
+func start(t *testing.T, s *Scheduler) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		assert.NoError(t, s.Start(ctx, &wg)())
+	}()
+	return func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("scheduler not stopped")
+		}
+	}
+}
+
+func TestOverlapSkip(t *testing.T) {
+	r := &recorder{}
+	release := make(chan struct{})
+	stop := start(t, New([]Option{WithJobs(Job{Name: "skip", Schedule: tick(10 * time.Millisecond), Run: r.run(release)})}))
+
+	// the runs scheduled while the first one blocks are skipped
+	time.Sleep(100 * time.Millisecond)
+	runs, _ := r.count()
+	assert.Equal(t, 1, runs)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		runs, _ := r.count()
+		return runs > 1
+	}, 5*time.Second, 10*time.Millisecond)
+	stop()
+
+	_, concurrency := r.count()
+	assert.Equal(t, 1, concurrency)
+}
+
+func TestOverlapWaitRuns(t *testing.T) {
+	r := &recorder{}
+	release := make(chan struct{})
+	stop := start(t, New([]Option{WithJobs(Job{Name: "wait", Schedule: tick(10 * time.Millisecond), Run: r.run(release), Overlap: OverlapWait})}))
+
+	time.Sleep(100 * time.Millisecond)
+	runs, _ := r.count()
+	assert.Equal(t, 1, runs)
+
+	// the missed runs are merged, the next ones wait for the previous one
+	close(release)
+	assert.Eventually(t, func() bool {
+		runs, _ := r.count()
+		return runs > 2
+	}, 5*time.Second, 10*time.Millisecond)
+	stop()
+
+	runs, concurrency := r.count()
+	assert.Equal(t, 1, concurrency)
+	assert.True(t, runs < 50, "%d runs", runs)
+}
+
+func TestShutdownCancelsRuns(t *testing.T) {
+	r := &recorder{}
+	stop := start(t, New([]Option{WithJobs(Job{Name: "blocked", Schedule: tick(10 * time.Millisecond), Run: r.run(nil)})}))
+
+	assert.Eventually(t, func() bool {
+		runs, _ := r.count()
+		return runs > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the run blocks until its context is canceled by the shutdown
+	stop()
+}
+
+// lockRecorder refuses every lock and records their TTL
+type lockRecorder struct {
+	mutex sync.Mutex
+	ttls  map[string]time.Duration
+}
+
+func (l *lockRecorder) Lock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.ttls[key] = ttl
+	return false, nil
+}
+
+func TestLockTTL(t *testing.T) {
+	locker := &lockRecorder{ttls: map[string]time.Duration{}}
+	s := New([]Option{WithLocker(locker), WithLockPrefix("payment")})
+	scheduled := time.Date(2020, 3, 8, 0, 0, 0, 0, time.UTC)
+	run := func(ctx context.Context, session *Session.Session) error {
+		t.Error("run without lock")
+		return nil
+	}
+
+	s.run(context.Background(), Job{Name: "weekly", Schedule: MustCron("@weekly"), Run: run}, scheduled)
+	s.run(context.Background(), Job{Name: "hourly", Schedule: Every(time.Hour), Run: run}, scheduled)
+
+	assert.Equal(t, map[string]time.Duration{
+		"payment/weekly/1583625600": MaximumLockTTL,
+		"payment/hourly/1583625600": time.Hour,
+	}, locker.ttls)
+}
@@ -3,29 +3,51 @@ package transport
 import (
 	"context"
 	"fmt"
+	"github.com/agitdevcenter/gopkg/health"
 	Logger "github.com/agitdevcenter/gopkg/logger"
+	"github.com/agitdevcenter/gopkg/transport/admin"
 	"github.com/agitdevcenter/gopkg/transport/custom"
 	"github.com/agitdevcenter/gopkg/transport/grpc"
 	"github.com/agitdevcenter/gopkg/transport/http"
-	"golang.org/x/sync/errgroup"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 )
 
 type Transport struct {
 	inherit    bool
 	httpServer *http.Server
 	grpcServer *grpc.Server
+	admin      *admin.Admin
 	logger     Logger.Logger
 	debug      bool
 	services   []*custom.Holder
+
+	dependencies    map[string][]string
+	startupTimeout  time.Duration
+	shutdownTimeout time.Duration
+	drainPeriod     time.Duration
+	beforeStart     []Hook
+	afterStart      []Hook
+	beforeStop      []Hook
+	afterStop       []Hook
+	reload          []Hook
+	reloadMutex     sync.Mutex
+	gracefulRestart bool
+	restartTimeout  time.Duration
+	restarting      int32
+	healthRegistry  *health.Registry
 }
 
 func New(opts []Option) *Transport {
 	t := &Transport{
-		inherit: true,
+		inherit:        true,
+		dependencies:   make(map[string][]string),
+		healthRegistry: health.DefaultRegistry,
 	}
 
 	for _, opt := range opts {
@@ -51,8 +73,14 @@ func (t *Transport) SetGRPC(grpcServer *grpc.Server) {
 	t.inheritGRPCServer()
 }
 
+func (t *Transport) SetAdmin(admin *admin.Admin) {
+	t.admin = admin
+	t.inheritAdmin()
+}
+
 func (t *Transport) inheritServer() {
 	t.inheritHTTPServer()
+	t.inheritAdmin()
 	t.inheritGRPCServer()
 }
 
@@ -60,6 +88,7 @@ func (t *Transport) inheritHTTPServer() {
 	if t.inherit && t.httpServer != nil {
 		t.httpServer.SetDebug(t.debug)
 		t.httpServer.SetLogger(t.logger)
+		t.httpServer.SetHealthRegistry(t.healthRegistry)
 	}
 }
 
@@ -67,9 +96,29 @@ func (t *Transport) inheritGRPCServer() {
 	if t.inherit && t.grpcServer != nil {
 		t.grpcServer.SetDebug(t.debug)
 		t.grpcServer.SetLogger(t.logger)
+		t.grpcServer.SetHealthRegistry(t.healthRegistry)
+	}
+}
+
+// inheritAdmin the admin server serves the health registry of the transport, reloads the transport and toggles the
+// availability of the HTTP server
+func (t *Transport) inheritAdmin() {
+	if t.inherit && t.admin != nil {
+		t.admin.SetDebug(t.debug)
+		t.admin.SetLogger(t.logger)
+		t.admin.SetHealthRegistry(t.healthRegistry)
+		t.admin.SetReloader(t.Reload)
+		if t.httpServer != nil && t.httpServer.Middleware() != nil {
+			t.admin.SetMiddleware(t.httpServer.Middleware())
+		}
 	}
 }
 
+func (t *Transport) SetupAdmin(opts []admin.Option) {
+	t.admin = admin.New(opts)
+	t.inheritAdmin()
+}
+
 func (t *Transport) SetupHTTP(opts []http.Option) {
 	t.httpServer = http.New(opts)
 	t.inheritHTTPServer()
@@ -80,67 +129,146 @@ func (t *Transport) SetupGRPC(opts []grpc.Option) {
 	t.inheritGRPCServer()
 }
 
+// Run starts the servers and custom services in dependency order and stops them in reverse order
+// on a signal or the first error, SIGHUP reloads them instead
 func (t *Transport) Run() (err error) {
+	components, err := t.components()
+	if err != nil {
+		return
+	}
 
-	serverAvailable := 0
-	if t.httpServer != nil {
-		serverAvailable++
+	if err = t.inheritListeners(); err != nil {
+		return
 	}
-	if t.grpcServer != nil {
-		serverAvailable++
+
+	l := newLifecycle(t)
+
+	// not ready until every server and service is ready
+	t.healthRegistry.SetReady(false)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Kill, os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+
+	reloads := make(chan os.Signal, 1)
+	signal.Notify(reloads, syscall.SIGHUP)
+	defer signal.Stop(reloads)
+
+	restarts := make(chan os.Signal, 1)
+	if t.gracefulRestart && RestartSignal != nil {
+		signal.Notify(restarts, RestartSignal)
+		defer signal.Stop(restarts)
 	}
 
-	if len(t.services) > 0 {
-		serverAvailable = serverAvailable + len(t.services)
+	go func() {
+		for {
+			select {
+			case <-reloads:
+				if err := t.Reload(context.Background()); err != nil {
+					t.logger.Error(fmt.Sprintf("reload error : %+v", err))
+				}
+			case <-restarts:
+				go t.gracefulRestartOnce(l)
+			case <-signals:
+				l.stop()
+				return
+			case <-l.stopped:
+				return
+			}
+		}
+	}()
+
+	if err = l.start(components); err != nil {
+		return
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(serverAvailable)
+	select {
+	case <-l.ready:
+		t.healthRegistry.SetReady(true)
+		if err := notifyParent(); err != nil {
+			t.logger.Error(fmt.Sprintf("graceful restart, notifying parent process error : %+v", err))
+		}
+	default:
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	<-l.stopped
 
-	eg, egc := errgroup.WithContext(context.Background())
+	if err = l.shutdown(); err != nil {
+		return
+	}
 
-	// http services
-	if t.httpServer != nil {
-		eg.Go(t.httpServer.Start(ctx, &wg))
+	if t.debug {
+		t.logger.Info("all server closed successfully")
 	}
 
-	// grpc service
+	return
+}
+
+// Reload reloads the logger, servers and custom services implementing custom.Reloadable then runs the reload hooks.
+// Everything is reloaded even when one of them fails, the errors are returned together.
+func (t *Transport) Reload(ctx context.Context) error {
+	t.reloadMutex.Lock()
+	defer t.reloadMutex.Unlock()
+
+	var errors []string
+	reload := func(name string, service interface{}) {
+		if reloadable, ok := service.(custom.Reloadable); ok {
+			if err := reloadable.Reload(ctx); err != nil {
+				errors = append(errors, fmt.Sprintf("%s : %+v", name, err))
+			}
+		}
+	}
+
+	reload("logger", t.logger)
+	if t.httpServer != nil {
+		reload(HTTP, t.httpServer)
+	}
 	if t.grpcServer != nil {
-		eg.Go(t.grpcServer.Start(ctx, &wg))
+		reload(GRPC, t.grpcServer)
+	}
+	if t.admin != nil {
+		reload(ADMIN, t.admin)
+	}
+	for i, h := range t.services {
+		name := h.Name()
+		if len(name) == 0 {
+			name = fmt.Sprintf("custom-%d", i)
+		}
+		reload(name, h.Service())
 	}
 
-	// custom services
-	for _, h := range t.services {
-		if t.inherit {
-			h.Service().SetLogger(t.logger)
-			h.Service().SetDebug(t.debug)
+	for _, hook := range t.reload {
+		if err := hook(ctx); err != nil {
+			errors = append(errors, fmt.Sprintf("reload hook : %+v", err))
 		}
-		h.Hold()
-		eg.Go(h.Service().Start(ctx, &wg))
 	}
 
-	go func() {
-		<-egc.Done()
-		cancel()
-	}()
+	if len(errors) > 0 {
+		return fmt.Errorf("reload error %s", strings.Join(errors, ", "))
+	}
 
-	go func() {
-		signals := make(chan os.Signal, 1)
-		signal.Notify(signals, os.Kill, os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL, syscall.SIGHUP, syscall.SIGQUIT)
-		<-signals
-		cancel()
-	}()
+	if t.debug {
+		t.logger.Info("reloaded successfully")
+	}
+
+	return nil
+}
 
-	if err = eg.Wait(); err != nil {
-		err = fmt.Errorf("server error : %+v", err)
+// gracefulRestartOnce hands the listeners over to a new process then drains and stops this one,
+// this process keeps running when the new process fails to start
+func (t *Transport) gracefulRestartOnce(l *lifecycle) {
+	if !atomic.CompareAndSwapInt32(&t.restarting, 0, 1) {
 		return
 	}
+	defer atomic.StoreInt32(&t.restarting, 0)
 
-	if t.debug {
-		t.logger.Info("all server closed successfully")
+	if err := t.restart(); err != nil {
+		t.logger.Error(fmt.Sprintf("graceful restart error : %+v", err))
+		return
 	}
 
-	return
+	if t.debug {
+		t.logger.Info("graceful restart, new process is ready, stopping")
+	}
+	l.stop()
 }
@@ -0,0 +1,32 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agitdevcenter/gopkg/health"
+	"github.com/agitdevcenter/gopkg/transport/http"
+	"github.com/agitdevcenter/gopkg/transport/http/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInheritHealthRegistry(t *testing.T) {
+	registry := health.NewRegistry()
+	assert.NoError(t, registry.Register(health.Check{Name: "mysql", Critical: true, Checker: health.CheckerFunc(func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})}))
+
+	m := middleware.New([]middleware.Option{middleware.WithHealth("/health")})
+	New([]Option{WithHealthRegistry(registry), WithHTTPServer(http.New([]http.Option{http.WithMiddleware(m)}))})
+
+	// the health URL of the HTTP server runs the checks of the transport registry
+	e := echo.New()
+	m.Setup(e)
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(nethttp.MethodGet, "/health", nil))
+	assert.Equal(t, nethttp.StatusServiceUnavailable, recorder.Code)
+}
@@ -0,0 +1,271 @@
+package vo
+
+import (
+	"bytes"
+	standardJSON "encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	SourcePath   = "path"
+	SourceQuery  = "query"
+	SourceHeader = "header"
+	SourceBody   = "body"
+
+	RuleType    = "type"
+	RuleUnknown = "unknown"
+)
+
+// sourceTags struct tags binding a field from a request source, the json tag binds the body
+var sourceTags = []struct {
+	tag    string
+	source string
+}{
+	{"param", SourcePath},
+	{"query", SourceQuery},
+	{"header", SourceHeader},
+}
+
+var conversionMessages = map[string]string{
+	"en": "%s must be a valid %s",
+	"id": "%s harus berupa %s yang valid",
+}
+
+var unknownFieldMessages = map[string]string{
+	"en": "%s is not allowed",
+	"id": "%s tidak diperbolehkan",
+}
+
+// bindBody decodes a JSON body with encoding/json to report typed field errors,
+// other content types go through the echo binder
+func bindBody(c echo.Context, requestModel interface{}, strict bool) (fields []FieldError, err error) {
+	request := c.Request()
+	// without body the echo binder is skipped, it would fail on the path params and query before bindSources
+	if request.Body == nil || request.ContentLength == 0 {
+		return
+	}
+
+	if !strings.HasPrefix(request.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		return nil, c.Bind(requestModel)
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return
+	}
+
+	decoder := standardJSON.NewDecoder(bytes.NewReader(body))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err = decoder.Decode(requestModel); err != nil && err != io.EOF {
+		languages := AcceptLanguages(request)
+		if typeError, ok := err.(*standardJSON.UnmarshalTypeError); ok {
+			return []FieldError{{
+				Field:   typeError.Field,
+				Source:  SourceBody,
+				Rule:    RuleType,
+				Param:   typeError.Type.String(),
+				Message: localize(conversionMessages, languages, typeError.Field, typeError.Type.String()),
+			}}, nil
+		}
+		if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+			return []FieldError{{
+				Field:   field,
+				Source:  SourceBody,
+				Rule:    RuleUnknown,
+				Message: localize(unknownFieldMessages, languages, field),
+			}}, nil
+		}
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// bindSources sets fields tagged with param, query and header, conversion failures are returned as field errors
+func bindSources(c echo.Context, requestModel interface{}) (fields []FieldError) {
+	value := reflect.ValueOf(requestModel)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	languages := AcceptLanguages(c.Request())
+	walkFields(value.Elem(), func(field reflect.StructField, target reflect.Value) {
+		for _, source := range sourceTags {
+			name := field.Tag.Get(source.tag)
+			if len(name) == 0 || name == "-" {
+				continue
+			}
+
+			values := sourceValues(c, source.source, name)
+			if len(values) == 0 {
+				continue
+			}
+
+			if err := setField(target, values); err != nil {
+				fields = append(fields, FieldError{
+					Field:   name,
+					Source:  source.source,
+					Rule:    RuleType,
+					Param:   target.Type().String(),
+					Message: localize(conversionMessages, languages, name, target.Type().String()),
+				})
+			}
+		}
+	})
+
+	return
+}
+
+// fieldSources maps the json name of every source tagged field to its source
+func fieldSources(requestModel interface{}) map[string]string {
+	sources := make(map[string]string)
+
+	value := reflect.ValueOf(requestModel)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return sources
+	}
+
+	walkFields(value.Elem(), func(field reflect.StructField, target reflect.Value) {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if len(name) == 0 || name == "-" {
+			name = field.Name
+		}
+		for _, source := range sourceTags {
+			if tag := field.Tag.Get(source.tag); len(tag) > 0 && tag != "-" {
+				sources[name] = source.source
+				sources[field.Name] = source.source
+			}
+		}
+	})
+
+	return sources
+}
+
+func walkFields(value reflect.Value, fn func(field reflect.StructField, target reflect.Value)) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		target := value.Field(i)
+
+		if field.Anonymous && target.Kind() == reflect.Struct {
+			walkFields(target, fn)
+			continue
+		}
+
+		if len(field.PkgPath) > 0 || !target.CanSet() {
+			continue
+		}
+
+		fn(field, target)
+	}
+}
+
+func sourceValues(c echo.Context, source, name string) []string {
+	switch source {
+	case SourcePath:
+		if value := c.Param(name); len(value) > 0 {
+			return []string{value}
+		}
+	case SourceQuery:
+		return c.QueryParams()[name]
+	case SourceHeader:
+		return c.Request().Header[http.CanonicalHeaderKey(name)]
+	}
+	return nil
+}
+
+func setField(target reflect.Value, values []string) error {
+	if target.Kind() == reflect.Ptr {
+		element := reflect.New(target.Type().Elem())
+		if err := setField(element.Elem(), values); err != nil {
+			return err
+		}
+		target.Set(element)
+		return nil
+	}
+
+	if target.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(target.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+		return nil
+	}
+
+	return setValue(target, values[0])
+}
+
+func setValue(target reflect.Value, value string) error {
+	if target.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		target.SetInt(int64(duration))
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		target.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported type %s", target.Type())
+	}
+	return nil
+}
+
+func localize(messages map[string]string, languages []string, args ...interface{}) string {
+	format := messages["en"]
+	for _, language := range languages {
+		if message, ok := messages[language]; ok {
+			format = message
+			break
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,198 @@
+package vo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	Error "github.com/agitdevcenter/gopkg/error"
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Response "github.com/agitdevcenter/gopkg/response"
+	Session "github.com/agitdevcenter/gopkg/session"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type noopValidator struct{}
+
+func (noopValidator) Validate(i interface{}) error {
+	return nil
+}
+
+type bindRequest struct {
+	ID       int64         `json:"id" param:"id"`
+	Page     *int          `json:"page" query:"page"`
+	Tags     []string      `json:"tags" query:"tag"`
+	Active   bool          `json:"active" query:"active"`
+	Timeout  time.Duration `json:"timeout" query:"timeout"`
+	Ratio    float64       `json:"ratio" query:"ratio"`
+	Count    uint8         `json:"count" query:"count"`
+	Merchant string        `json:"merchant" header:"X-Merchant-ID"`
+	Version  int           `json:"version" header:"X-Version"`
+	Name     string        `json:"name"`
+	Amount   int           `json:"amount"`
+}
+
+func newBindContext(target, id, body string, headers map[string]string) *ApplicationContext {
+	e := echo.New()
+	e.Validator = noopValidator{}
+	// sizes the path params of the contexts
+	e.POST("/users/:id", func(c echo.Context) error { return nil })
+
+	request := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if len(body) > 0 {
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	c := e.NewContext(request, httptest.NewRecorder())
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+	return &ApplicationContext{Context: c, Session: Session.New(Logger.Noop())}
+}
+
+func TestBindSources(t *testing.T) {
+	c := newBindContext("/users/42?page=3&tag=a&tag=b&active=true&timeout=1m30s&ratio=0.5&count=7",
+		"42", `{"name":"budi","amount":10000}`, map[string]string{"X-Merchant-ID": "M001", "X-Version": "2"})
+
+	var request bindRequest
+	assert.NoError(t, c.BindRequest(&request))
+
+	page := 3
+	assert.Equal(t, bindRequest{
+		ID:       42,
+		Page:     &page,
+		Tags:     []string{"a", "b"},
+		Active:   true,
+		Timeout:  90 * time.Second,
+		Ratio:    0.5,
+		Count:    7,
+		Merchant: "M001",
+		Version:  2,
+		Name:     "budi",
+		Amount:   10000,
+	}, request)
+}
+
+func TestBindErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		id       string
+		body     string
+		headers  map[string]string
+		strict   bool
+		expected []FieldError
+	}{
+		{
+			name:     "path",
+			target:   "/users/abc",
+			id:       "abc",
+			expected: []FieldError{{Field: "id", Source: SourcePath, Rule: RuleType, Param: "int64", Message: "id must be a valid int64"}},
+		},
+		{
+			name:     "query",
+			target:   "/users/1?page=first",
+			id:       "1",
+			expected: []FieldError{{Field: "page", Source: SourceQuery, Rule: RuleType, Param: "*int", Message: "page must be a valid *int"}},
+		},
+		{
+			name:   "query overflow and bool",
+			target: "/users/1?count=300&active=maybe",
+			id:     "1",
+			expected: []FieldError{
+				{Field: "active", Source: SourceQuery, Rule: RuleType, Param: "bool", Message: "active must be a valid bool"},
+				{Field: "count", Source: SourceQuery, Rule: RuleType, Param: "uint8", Message: "count must be a valid uint8"},
+			},
+		},
+		{
+			name:     "query duration",
+			target:   "/users/1?timeout=soon",
+			id:       "1",
+			expected: []FieldError{{Field: "timeout", Source: SourceQuery, Rule: RuleType, Param: "time.Duration", Message: "timeout must be a valid time.Duration"}},
+		},
+		{
+			name:     "header",
+			target:   "/users/1",
+			id:       "1",
+			headers:  map[string]string{"X-Version": "v2"},
+			expected: []FieldError{{Field: "X-Version", Source: SourceHeader, Rule: RuleType, Param: "int", Message: "X-Version must be a valid int"}},
+		},
+		{
+			name:     "body type indonesian",
+			target:   "/users/1",
+			id:       "1",
+			headers:  map[string]string{HeaderAcceptLanguage: "id-ID,id;q=0.9"},
+			body:     `{"amount":"sepuluh"}`,
+			expected: []FieldError{{Field: "amount", Source: SourceBody, Rule: RuleType, Param: "int", Message: "amount harus berupa int yang valid"}},
+		},
+		{
+			name:     "body type",
+			target:   "/users/1",
+			id:       "1",
+			body:     `{"name":12}`,
+			expected: []FieldError{{Field: "name", Source: SourceBody, Rule: RuleType, Param: "string", Message: "name must be a valid string"}},
+		},
+		{
+			name:   "unknown field",
+			target: "/users/1",
+			id:     "1",
+			body:   `{"name":"budi","role":"admin"}`,
+		},
+		{
+			name:     "unknown field strict",
+			target:   "/users/1",
+			id:       "1",
+			body:     `{"name":"budi","role":"admin"}`,
+			strict:   true,
+			expected: []FieldError{{Field: "role", Source: SourceBody, Rule: RuleUnknown, Message: "role is not allowed"}},
+		},
+		{
+			name:     "unknown field strict indonesian",
+			target:   "/users/1",
+			id:       "1",
+			body:     `{"role":"admin"}`,
+			headers:  map[string]string{HeaderAcceptLanguage: "id"},
+			strict:   true,
+			expected: []FieldError{{Field: "role", Source: SourceBody, Rule: RuleUnknown, Message: "role tidak diperbolehkan"}},
+		},
+	}
+
+	for _, test := range tests {
+		c := newBindContext(test.target, test.id, test.body, test.headers)
+
+		var request bindRequest
+		var err error
+		if test.strict {
+			err = c.BindRequestStrict(&request)
+		} else {
+			err = c.BindRequest(&request)
+		}
+
+		if test.expected == nil {
+			assert.NoError(t, err, test.name)
+			continue
+		}
+
+		he, ok := Error.As(err)
+		if assert.True(t, ok, test.name) {
+			assert.Equal(t, Response.ErrorInvalidJson, he.ErrorCode, test.name)
+			assert.Equal(t, test.expected, he.Details, test.name)
+		}
+	}
+}
+
+func TestBindInvalidJSON(t *testing.T) {
+	c := newBindContext("/users/1", "1", `{"name":`, nil)
+
+	var request bindRequest
+	he, ok := Error.As(c.BindRequest(&request))
+	if assert.True(t, ok) {
+		assert.Equal(t, Response.ErrorInvalidJson, he.ErrorCode)
+		assert.Nil(t, he.Details)
+	}
+}
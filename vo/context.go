@@ -1,8 +1,9 @@
 package vo
 
 import (
+	"context"
 	"github.com/labstack/echo/v4"
-	"net/http"
+	"strings"
 
 	Error "github.com/agitdevcenter/gopkg/error"
 	Response "github.com/agitdevcenter/gopkg/response"
@@ -13,25 +14,68 @@ const AppSession = "App_Session"
 
 type ApplicationContext struct {
 	echo.Context
-	Session Session.Session
+	Session *Session.Session
 }
 
 func Parse(c echo.Context) *ApplicationContext {
-	data := c.Get(AppSession)
-	session := data.(Session.Session)
+	session, ok := Session.FromContext(c.Request().Context())
+	if !ok {
+		session, _ = c.Get(AppSession).(*Session.Session)
+	}
 	return &ApplicationContext{Context: c, Session: session}
 }
 
+// Ctx request context carrying the session, pass it to rest and gRPC clients
+func (c *ApplicationContext) Ctx() context.Context {
+	return c.Request().Context()
+}
+
 // - validate payload
+// BindRequest binds path params, query, headers and body using the param, query, header and json tags then validates the payload
 func (c *ApplicationContext) BindRequest(requestModel interface{}) error {
+	return c.bindRequest(requestModel, false)
+}
+
+// BindRequestStrict same as BindRequest, rejecting unknown JSON body fields
+func (c *ApplicationContext) BindRequestStrict(requestModel interface{}) error {
+	return c.bindRequest(requestModel, true)
+}
+
+func (c *ApplicationContext) bindRequest(requestModel interface{}, strict bool) error {
 	timeProcess := c.Session.T2("ApplicationContext:BindRequest")
 
-	if err := c.Bind(requestModel); err != nil {
+	fields, err := bindBody(c.Context, requestModel, strict)
+	if err != nil {
 		return Error.New(Response.ErrorInvalidJson, err.Error())
 	}
 
+	fields = append(fields, bindSources(c.Context, requestModel)...)
+	if len(fields) > 0 {
+		return Error.NewWithDetails(Response.ErrorInvalidJson, fieldMessages(fields), fields)
+	}
+
 	if err := c.Validate(requestModel); err != nil {
-		return Error.New(Response.ErrorInvalidJson, err.Error())
+		if fieldValidator, ok := c.Echo().Validator.(FieldValidator); ok {
+			fields = fieldValidator.FieldErrors(err, AcceptLanguages(c.Request())...)
+		} else {
+			fields = FieldErrors(err)
+		}
+
+		if len(fields) == 0 {
+			return Error.New(Response.ErrorInvalidJson, err.Error())
+		}
+
+		sources := fieldSources(requestModel)
+		for i := range fields {
+			root := strings.SplitN(fields[i].Field, ".", 2)[0]
+			if source, ok := sources[root]; ok {
+				fields[i].Source = source
+			} else {
+				fields[i].Source = SourceBody
+			}
+		}
+
+		return Error.NewWithDetails(Response.ErrorInvalidJson, fieldMessages(fields), fields)
 	}
 
 	c.Session.T3(timeProcess, requestModel)
@@ -40,72 +84,79 @@ func (c *ApplicationContext) BindRequest(requestModel interface{}) error {
 
 // - Response
 func (c *ApplicationContext) Ok(data interface{}) error {
+	return c.respond(Response.SuccessCode, c.Message(Response.SuccessCode), data, nil)
+}
 
-	if data == nil {
-		data = struct{}{}
-	}
-
-	response := Response.DefaultResponse{
-		Response: Response.Response{
-			Status:  Response.SuccessCode,
-			Message: "Success",
-		},
-		Data: data,
+// Response an empty message is taken from the response code catalogue in the request language
+func (c *ApplicationContext) Response(status string, message string, data interface{}) error {
+	if len(message) == 0 {
+		message = c.Message(status)
 	}
-
-	c.Session.T4(response)
-	return c.Context.JSON(http.StatusOK, response)
+	return c.respond(status, message, data, nil)
 }
 
-func (c *ApplicationContext) Response(status string, message string, data interface{}) error {
+// Message of a response code from the catalogue in the request language
+func (c *ApplicationContext) Message(status string) string {
+	return Response.Message(status, AcceptLanguages(c.Request())...)
+}
 
-	if data == nil {
-		data = struct{}{}
+func (c *ApplicationContext) Error(err error, data interface{}) error {
+	status := Response.GeneralError
+	var message string
+
+	if he, ok := Error.As(err); ok {
+		status = he.ErrorCode
+		message = he.Error()
+		if data == nil && he.Details != nil {
+			data = he.Details
+		}
+	} else if he, ok := err.(*echo.HTTPError); ok {
+		message = he.Error()
+	} else {
+		message = err.Error()
 	}
 
-	response := Response.DefaultResponse{
-		Response: Response.Response{
-			Status:  status,
-			Message: message,
-		},
-		Data: data,
+	if len(message) == 0 {
+		message = c.Message(status)
 	}
 
-	c.Session.T4(response)
-	return c.Context.JSON(http.StatusOK, response)
+	return c.respond(status, message, data, err)
 }
 
-func (c *ApplicationContext) Error(err error, data interface{}) error {
-	code := Response.GeneralError
+// Responder configured by the middleware, DefaultResponder when none is set
+func (c *ApplicationContext) Responder() *Responder {
+	if responder, ok := c.Get(AppResponder).(*Responder); ok && responder != nil {
+		return responder
+	}
+	return DefaultResponder
+}
 
+func (c *ApplicationContext) respond(status, message string, data interface{}, err error) error {
 	if data == nil {
 		data = struct{}{}
 	}
 
-	response := Response.DefaultResponse{
-		Response: Response.Response{
-			Status:  code,
-			Message: "error",
-		},
-		Data: data,
-	}
+	responder := c.Responder()
+	return c.render(responder, status, responder.Wrap(status, message, data), err)
+}
 
-	if he, ok := err.(*Error.ApplicationError); ok {
-		response.Status = he.ErrorCode
-		response.Message = he.Error()
-	} else if he, ok := err.(*echo.HTTPError); ok {
-		response.Message = he.Error()
-	} else {
-		response.Message = err.Error()
+func (c *ApplicationContext) render(responder *Responder, status string, body interface{}, err error) error {
+	code := responder.HTTPStatus(status, err)
+	c.Set(AppResponseCode, status)
+
+	if c.Session != nil {
+		c.Session.SetResponseCode(status)
+		c.Session.T4(body)
+		c.Set(AlreadyLogged, true)
 	}
 
-	c.Session.T4(response)
-	return c.Context.JSON(http.StatusOK, response)
+	return responder.Render(c.Context, code, body)
 }
 
 // - Response
 func (c *ApplicationContext) Raw(status int, response interface{}) error {
 	c.Session.T4(response)
+	c.Set(AlreadyLogged, true)
 
 	return c.Context.JSON(status, response)
 }
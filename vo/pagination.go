@@ -0,0 +1,72 @@
+package vo
+
+import (
+	Error "github.com/agitdevcenter/gopkg/error"
+	"github.com/agitdevcenter/gopkg/pagination"
+	Response "github.com/agitdevcenter/gopkg/response"
+)
+
+var paginationMessages = map[string]map[string]string{
+	"min": {
+		"en": "%s must be a positive number",
+		"id": "%s harus berupa angka positif",
+	},
+	"max": {
+		"en": "%s exceeds the maximum allowed",
+		"id": "%s melebihi batas maksimum",
+	},
+	"oneof": {
+		"en": "%s contains a field that can not be sorted",
+		"id": "%s berisi field yang tidak dapat diurutkan",
+	},
+	"cursor": {
+		"en": "%s is not a valid cursor",
+		"id": "%s bukan cursor yang valid",
+	},
+}
+
+// PageEnvelope envelopes that also build paginated bodies, Responder falls back to Response.PaginatedResponse
+type PageEnvelope interface {
+	WrapPage(status, message string, data interface{}, meta Response.Meta) interface{}
+}
+
+// WrapPage builds a paginated body with the envelope when it implements PageEnvelope
+func (r *Responder) WrapPage(status, message string, data interface{}, meta Response.Meta) interface{} {
+	if envelope, ok := r.Envelope.(PageEnvelope); ok {
+		return envelope.WrapPage(status, message, data, meta)
+	}
+	return Response.CreatePaginatedResponse(status, message, data, meta)
+}
+
+// Pagination parses page, limit, offset, cursor and sort query parameters
+func (c *ApplicationContext) Pagination(options pagination.Options) (pagination.Page, error) {
+	page, err := pagination.Parse(c.QueryParams(), options)
+	if err == nil {
+		return page, nil
+	}
+
+	paramError, ok := err.(*pagination.ParamError)
+	if !ok {
+		return page, Error.New(Response.ErrorInvalidRequest, err.Error())
+	}
+
+	fields := []FieldError{{
+		Field:   paramError.Param,
+		Source:  SourceQuery,
+		Rule:    paramError.Rule,
+		Message: localize(paginationMessages[paramError.Rule], AcceptLanguages(c.Request()), paramError.Param),
+	}}
+	return page, Error.NewWithDetails(Response.ErrorInvalidRequest, fieldMessages(fields), fields)
+}
+
+// OkPage success response with pagination meta, build the meta with pagination.Page.Meta
+func (c *ApplicationContext) OkPage(data interface{}, meta Response.Meta) error {
+	if data == nil {
+		data = []interface{}{}
+	}
+
+	responder := c.Responder()
+	body := responder.WrapPage(Response.SuccessCode, c.Message(Response.SuccessCode), data, meta)
+
+	return c.render(responder, Response.SuccessCode, body, nil)
+}
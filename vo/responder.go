@@ -0,0 +1,287 @@
+package vo
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	Error "github.com/agitdevcenter/gopkg/error"
+	Response "github.com/agitdevcenter/gopkg/response"
+	"github.com/golang/protobuf/proto"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	AppResponder  = "App_Responder"
+	AlreadyLogged = "AlreadyLogged"
+	// AppResponseCode business code of the rendered response, counted by the middleware metrics
+	AppResponseCode = "App_ResponseCode"
+
+	MIMEApplicationProtobuf = "application/x-protobuf"
+)
+
+// Envelope builds the body written for a response status, message and data
+type Envelope interface {
+	Wrap(status, message string, data interface{}) interface{}
+}
+
+type EnvelopeFunc func(status, message string, data interface{}) interface{}
+
+func (f EnvelopeFunc) Wrap(status, message string, data interface{}) interface{} {
+	return f(status, message, data)
+}
+
+// StatusPolicy maps a response status code and the error being rendered, if any, to an HTTP status
+type StatusPolicy interface {
+	HTTPStatus(status string, err error) int
+}
+
+type StatusPolicyFunc func(status string, err error) int
+
+func (f StatusPolicyFunc) HTTPStatus(status string, err error) int {
+	return f(status, err)
+}
+
+// Renderer writes a body with one content type
+type Renderer interface {
+	ContentType() string
+	CanRender(body interface{}) bool
+	Render(c echo.Context, code int, body interface{}) error
+}
+
+// Responder envelope, status policy and renderers used by ApplicationContext,
+// the first renderer is used when nothing in the Accept header matches
+type Responder struct {
+	Envelope     Envelope
+	StatusPolicy StatusPolicy
+	Renderers    []Renderer
+}
+
+// DefaultResponder Response.DefaultResponse as JSON with HTTP 200
+var DefaultResponder = &Responder{
+	Envelope:     DefaultEnvelope,
+	StatusPolicy: OkStatusPolicy,
+	Renderers:    []Renderer{JSONRenderer},
+}
+
+var DefaultEnvelope = EnvelopeFunc(func(status, message string, data interface{}) interface{} {
+	return Response.CreateResponse(status, message, data)
+})
+
+// OkStatusPolicy always answers HTTP 200, the status is carried by the envelope
+var OkStatusPolicy = StatusPolicyFunc(func(status string, err error) int {
+	return http.StatusOK
+})
+
+// RegistryStatusPolicy HTTP status of the error definition registered for the rendered error,
+// echo.HTTPError keeps its own code, HTTP 200 otherwise
+var RegistryStatusPolicy = StatusPolicyFunc(func(status string, err error) int {
+	if he, ok := err.(*echo.HTTPError); ok {
+		return he.Code
+	}
+	if code := Error.HTTPStatus(err); code > 0 {
+		return code
+	}
+	return http.StatusOK
+})
+
+// StatusMap maps response status codes to HTTP statuses, echo.HTTPError keeps its own code and
+// errors with a registered HTTP status answer it
+type StatusMap struct {
+	Codes    map[string]int
+	Fallback int
+}
+
+func (s *StatusMap) HTTPStatus(status string, err error) int {
+	if he, ok := err.(*echo.HTTPError); ok {
+		return he.Code
+	}
+	if code := Error.HTTPStatus(err); code > 0 {
+		return code
+	}
+	if code, ok := s.Codes[status]; ok {
+		return code
+	}
+	if s.Fallback > 0 {
+		return s.Fallback
+	}
+	return http.StatusOK
+}
+
+// DefaultStatusMap HTTP statuses for the codes in the response package
+var DefaultStatusMap = &StatusMap{
+	Codes: map[string]int{
+		Response.SuccessCode:         http.StatusOK,
+		Response.ErrorInvalidRequest: http.StatusBadRequest,
+		Response.ErrorInvalidJson:    http.StatusBadRequest,
+		Response.GeneralError:        http.StatusInternalServerError,
+	},
+	Fallback: http.StatusOK,
+}
+
+// CatalogueStatusPolicy HTTP status of the error registry then of the response code catalogue,
+// HTTP 200 for codes without one
+var CatalogueStatusPolicy = StatusPolicyFunc(func(status string, err error) int {
+	if he, ok := err.(*echo.HTTPError); ok {
+		return he.Code
+	}
+	if code := Error.HTTPStatus(err); code > 0 {
+		return code
+	}
+	if code, ok := Response.Lookup(status); ok && code.HTTPStatus > 0 {
+		return code.HTTPStatus
+	}
+	return http.StatusOK
+})
+
+// Render writes body using the renderer negotiated from the Accept header
+func (r *Responder) Render(c echo.Context, code int, body interface{}) error {
+	return r.Negotiate(c.Request().Header.Get(echo.HeaderAccept), body).Render(c, code, body)
+}
+
+func (r *Responder) Negotiate(accept string, body interface{}) Renderer {
+	renderers := r.Renderers
+	if len(renderers) == 0 {
+		renderers = DefaultResponder.Renderers
+	}
+
+	for _, mediaType := range parseAccept(accept) {
+		for _, renderer := range renderers {
+			if matchMediaType(mediaType, renderer.ContentType()) && renderer.CanRender(body) {
+				return renderer
+			}
+		}
+	}
+
+	for _, renderer := range renderers {
+		if renderer.CanRender(body) {
+			return renderer
+		}
+	}
+
+	return JSONRenderer
+}
+
+// Wrap builds the body with the configured envelope, DefaultEnvelope when none is set
+func (r *Responder) Wrap(status, message string, data interface{}) interface{} {
+	if r.Envelope == nil {
+		return DefaultEnvelope.Wrap(status, message, data)
+	}
+	return r.Envelope.Wrap(status, message, data)
+}
+
+// HTTPStatus maps status and err with the configured policy, OkStatusPolicy when none is set
+func (r *Responder) HTTPStatus(status string, err error) int {
+	if r.StatusPolicy == nil {
+		return OkStatusPolicy.HTTPStatus(status, err)
+	}
+	return r.StatusPolicy.HTTPStatus(status, err)
+}
+
+var (
+	JSONRenderer     Renderer = &jsonRenderer{}
+	XMLRenderer      Renderer = &xmlRenderer{}
+	ProtobufRenderer Renderer = &protobufRenderer{}
+)
+
+type jsonRenderer struct{}
+
+func (r *jsonRenderer) ContentType() string {
+	return echo.MIMEApplicationJSON
+}
+
+func (r *jsonRenderer) CanRender(body interface{}) bool {
+	return true
+}
+
+func (r *jsonRenderer) Render(c echo.Context, code int, body interface{}) error {
+	return c.JSON(code, body)
+}
+
+type xmlRenderer struct{}
+
+func (r *xmlRenderer) ContentType() string {
+	return echo.MIMEApplicationXML
+}
+
+// CanRender encoding/xml can not marshal maps, those bodies fall back to the next renderer
+func (r *xmlRenderer) CanRender(body interface{}) bool {
+	_, err := xml.Marshal(body)
+	return err == nil
+}
+
+func (r *xmlRenderer) Render(c echo.Context, code int, body interface{}) error {
+	return c.XML(code, body)
+}
+
+type protobufRenderer struct{}
+
+func (r *protobufRenderer) ContentType() string {
+	return MIMEApplicationProtobuf
+}
+
+func (r *protobufRenderer) CanRender(body interface{}) bool {
+	_, ok := body.(proto.Message)
+	return ok
+}
+
+func (r *protobufRenderer) Render(c echo.Context, code int, body interface{}) error {
+	data, err := proto.Marshal(body.(proto.Message))
+	if err != nil {
+		return Error.New(Response.GeneralError, err.Error())
+	}
+	return c.Blob(code, MIMEApplicationProtobuf, data)
+}
+
+// parseAccept media types of the Accept header ordered by quality
+func parseAccept(accept string) []string {
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if len(mediaType) == 0 {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	mediaTypes := make([]string, len(ranges))
+	for i, r := range ranges {
+		mediaTypes[i] = r.mediaType
+	}
+	return mediaTypes
+}
+
+func matchMediaType(mediaRange, contentType string) bool {
+	if mediaRange == "*/*" || mediaRange == contentType {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(contentType, strings.TrimSuffix(mediaRange, "*"))
+	}
+	return contentType == MIMEApplicationProtobuf && mediaRange == "application/protobuf"
+}
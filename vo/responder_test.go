@@ -0,0 +1,142 @@
+package vo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	Error "github.com/agitdevcenter/gopkg/error"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	errNotFound := Error.Define(Error.Definition{Code: "V404", Message: "account not found", HTTPStatus: http.StatusNotFound})
+	wrapped := fmt.Errorf("inquiry : %w", errNotFound)
+	unregistered := Error.New("V51", "insufficient balance")
+	statusMap := &StatusMap{Codes: map[string]int{"V51": http.StatusUnprocessableEntity}}
+
+	tests := []struct {
+		name     string
+		policy   StatusPolicy
+		status   string
+		err      error
+		expected int
+	}{
+		{"default keeps 200 for registered errors", nil, "V404", errNotFound, http.StatusOK},
+		{"ok policy keeps 200 for registered errors", OkStatusPolicy, "V404", wrapped, http.StatusOK},
+		{"registry", RegistryStatusPolicy, "V404", wrapped, http.StatusNotFound},
+		{"registry unregistered", RegistryStatusPolicy, "V51", unregistered, http.StatusOK},
+		{"registry echo error", RegistryStatusPolicy, "99", echo.ErrForbidden, http.StatusForbidden},
+		{"status map registry first", statusMap, "V404", errNotFound, http.StatusNotFound},
+		{"status map code", statusMap, "V51", unregistered, http.StatusUnprocessableEntity},
+		{"catalogue registry first", CatalogueStatusPolicy, "V404", errNotFound, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		responder := &Responder{StatusPolicy: test.policy}
+		assert.Equal(t, test.expected, responder.HTTPStatus(test.status, test.err), test.name)
+	}
+}
+
+func TestParseAccept(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		expected []string
+	}{
+		{"missing", "", []string{}},
+		{"single", "application/json", []string{"application/json"}},
+		{"quality order", "application/xml;q=0.5, application/json", []string{"application/json", "application/xml"}},
+		{"stable on ties", "text/html, application/xml;q=0.9, application/json;q=0.9", []string{"text/html", "application/xml", "application/json"}},
+		{"wildcards last", "*/*;q=0.1, application/*;q=0.8, application/xml", []string{"application/xml", "application/*", "*/*"}},
+		{"refused ranges dropped", "application/xml;q=0, application/json;q=0.2", []string{"application/json"}},
+		{"invalid quality", "application/xml;q=high, application/json;q=0.5", []string{"application/xml", "application/json"}},
+		{"params and case", " Application/JSON ; charset=UTF-8 ; q=0.7 ,, ", []string{"application/json"}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, parseAccept(test.accept), test.name)
+	}
+}
+
+func TestMatchMediaType(t *testing.T) {
+	tests := []struct {
+		mediaRange  string
+		contentType string
+		expected    bool
+	}{
+		{"application/json", echo.MIMEApplicationJSON, true},
+		{"*/*", echo.MIMEApplicationXML, true},
+		{"application/*", echo.MIMEApplicationJSON, true},
+		{"text/*", echo.MIMEApplicationJSON, false},
+		{"application/xml", echo.MIMEApplicationJSON, false},
+		{"application/protobuf", MIMEApplicationProtobuf, true},
+		{"application/protobuf", echo.MIMEApplicationJSON, false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, matchMediaType(test.mediaRange, test.contentType), test.mediaRange+" "+test.contentType)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	type account struct {
+		ID string `json:"id" xml:"id"`
+	}
+	responder := &Responder{Renderers: []Renderer{JSONRenderer, XMLRenderer, ProtobufRenderer}}
+	message := &Error.StatusDetail{Code: "00"}
+
+	tests := []struct {
+		name     string
+		accept   string
+		body     interface{}
+		expected Renderer
+	}{
+		{"missing accept uses the first renderer", "", account{}, JSONRenderer},
+		{"exact", "application/xml", account{}, XMLRenderer},
+		{"quality", "application/json;q=0.4, application/xml;q=0.8", account{}, XMLRenderer},
+		{"wildcard subtype", "text/html, application/*;q=0.5", account{}, JSONRenderer},
+		{"any", "*/*", account{}, JSONRenderer},
+		{"protobuf alias", "application/protobuf", message, ProtobufRenderer},
+		{"renderer unable to render is skipped", "application/x-protobuf, application/xml;q=0.5", account{}, XMLRenderer},
+		{"xml unable to render maps", "application/xml", map[string]string{"id": "1"}, JSONRenderer},
+		{"no match falls back instead of 406", "text/html", account{}, JSONRenderer},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, responder.Negotiate(test.accept, test.body), test.name)
+	}
+
+	// responders without renderers use the default ones
+	assert.Equal(t, JSONRenderer, (&Responder{}).Negotiate("application/xml", account{}))
+}
+
+func TestRender(t *testing.T) {
+	type account struct {
+		ID string `json:"id" xml:"id"`
+	}
+	responder := &Responder{Renderers: []Renderer{JSONRenderer, XMLRenderer}}
+	body := account{ID: "1"}
+
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", echo.MIMEApplicationJSONCharsetUTF8},
+		{"application/xml", echo.MIMEApplicationXMLCharsetUTF8},
+		{"text/html", echo.MIMEApplicationJSONCharsetUTF8},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(test.accept) > 0 {
+			request.Header.Set(echo.HeaderAccept, test.accept)
+		}
+		recorder := httptest.NewRecorder()
+		assert.NoError(t, responder.Render(echo.New().NewContext(request, recorder), http.StatusOK, body))
+		assert.Equal(t, http.StatusOK, recorder.Code, test.accept)
+		assert.Equal(t, test.contentType, recorder.Header().Get(echo.HeaderContentType), test.accept)
+	}
+}
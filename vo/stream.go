@@ -0,0 +1,266 @@
+package vo
+
+import (
+	"bytes"
+	"context"
+	standardJSON "encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	Response "github.com/agitdevcenter/gopkg/response"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// AppResponseWriter response writer saved by the middleware before body dumping, streams write to it directly
+	AppResponseWriter = "App_ResponseWriter"
+
+	MIMETextEventStream = "text/event-stream"
+
+	CloseCompleted    = "completed"
+	CloseDisconnected = "client_disconnected"
+	CloseTimeout      = "timeout"
+	CloseError        = "error"
+)
+
+var (
+	ErrStreamingUnsupported = errors.New("response writer does not support flushing")
+	ErrStreamClosed         = errors.New("stream closed")
+)
+
+// StreamOptions heartbeat and lifetime of a stream
+type StreamOptions struct {
+	// Interval between heartbeats keeping proxies from closing an idle stream, zero disables them
+	Heartbeat time.Duration
+	// Written as heartbeat by Stream, SSE always writes a comment line. Empty disables heartbeats of chunked streams
+	HeartbeatData []byte
+	// Maximum lifetime of the stream, zero means until fn returns or the client disconnects
+	Timeout time.Duration
+}
+
+// Event a server-sent event, strings and bytes are written as is and other data as JSON
+type Event struct {
+	ID    string
+	Event string
+	Data  interface{}
+	Retry time.Duration
+}
+
+// StreamSummary response logged in the TDR when a stream ends
+type StreamSummary struct {
+	Events      int    `json:"events"`
+	Bytes       int64  `json:"bytes"`
+	Duration    int64  `json:"duration"` // ms
+	CloseReason string `json:"closeReason"`
+}
+
+// Stream flushed response shared by the handler and the heartbeat
+type Stream struct {
+	ctx      context.Context
+	response *echo.Response
+	mutex    sync.Mutex
+	events   int
+	bytes    int64
+	err      error
+}
+
+// Context done when the client disconnects or the stream times out
+func (s *Stream) Context() context.Context {
+	return s.ctx
+}
+
+// Send writes one server-sent event
+func (s *Stream) Send(event Event) error {
+	var data []byte
+	switch value := event.Data.(type) {
+	case nil:
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		var err error
+		if data, err = standardJSON.Marshal(value); err != nil {
+			return err
+		}
+	}
+
+	var buffer bytes.Buffer
+	if len(event.ID) > 0 {
+		buffer.WriteString("id: " + singleLine(event.ID) + "\n")
+	}
+	if len(event.Event) > 0 {
+		buffer.WriteString("event: " + singleLine(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		buffer.WriteString(fmt.Sprintf("retry: %d\n", event.Retry.Nanoseconds()/int64(time.Millisecond)))
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		buffer.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	buffer.WriteString("\n")
+
+	return s.write(buffer.Bytes(), true)
+}
+
+// Write writes and flushes one chunk, every write is counted as an event
+func (s *Stream) Write(p []byte) (int, error) {
+	if err := s.write(p, true); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *Stream) heartbeat(data []byte) {
+	s.write(data, false)
+}
+
+func (s *Stream) write(p []byte, event bool) (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if s.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+
+	var n int
+	if n, err = s.response.Write(p); err != nil {
+		// a failed write means the connection is gone
+		s.err = err
+		return
+	}
+	s.response.Flush()
+
+	s.bytes += int64(n)
+	if event {
+		s.events++
+	}
+	return nil
+}
+
+// SSE streams server-sent events to the client until fn returns, the client disconnects or the timeout expires.
+// The response bypasses body dumping and a summary TDR is written when the stream ends.
+func (c *ApplicationContext) SSE(options StreamOptions, fn func(stream *Stream) error) error {
+	header := c.Context.Response().Header()
+	header.Set(echo.HeaderContentType, MIMETextEventStream)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+
+	options.HeartbeatData = []byte(": heartbeat\n\n")
+	return c.stream(options, fn)
+}
+
+// Stream writes a chunked response of contentType, every write is flushed to the client
+func (c *ApplicationContext) Stream(contentType string, options StreamOptions, fn func(stream *Stream) error) error {
+	header := c.Context.Response().Header()
+	header.Set(echo.HeaderContentType, contentType)
+	header.Set("X-Accel-Buffering", "no")
+
+	return c.stream(options, fn)
+}
+
+func (c *ApplicationContext) stream(options StreamOptions, fn func(stream *Stream) error) error {
+	start := time.Now()
+
+	// write to the writer wrapped by body dumping so nothing is buffered
+	if writer, ok := c.Get(AppResponseWriter).(http.ResponseWriter); ok {
+		c.Context.Response().Writer = writer
+	}
+	if _, ok := c.Context.Response().Writer.(http.Flusher); !ok {
+		return ErrStreamingUnsupported
+	}
+	c.Set(AlreadyLogged, true)
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if options.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(c.Request().Context(), options.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(c.Request().Context())
+	}
+	defer cancel()
+
+	stream := &Stream{ctx: ctx, response: c.Context.Response()}
+
+	c.Context.Response().WriteHeader(http.StatusOK)
+	c.Context.Response().Flush()
+
+	// the heartbeat is stopped before returning, the response must not be written once the handler is done
+	done := make(chan struct{})
+	var heartbeat sync.WaitGroup
+	defer func() {
+		close(done)
+		heartbeat.Wait()
+	}()
+	if options.Heartbeat > 0 && len(options.HeartbeatData) > 0 {
+		heartbeat.Add(1)
+		go func() {
+			defer heartbeat.Done()
+			ticker := time.NewTicker(options.Heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					stream.heartbeat(options.HeartbeatData)
+				case <-ctx.Done():
+					return
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	err := fn(stream)
+
+	stream.mutex.Lock()
+	writeErr := stream.err
+	summary := StreamSummary{
+		Events:   stream.events,
+		Bytes:    stream.bytes,
+		Duration: time.Since(start).Nanoseconds() / int64(time.Millisecond),
+	}
+	stream.mutex.Unlock()
+
+	switch {
+	case c.Request().Context().Err() != nil || writeErr != nil:
+		summary.CloseReason = CloseDisconnected
+	case ctx.Err() == context.DeadlineExceeded:
+		summary.CloseReason = CloseTimeout
+	case err != nil && err != ErrStreamClosed:
+		summary.CloseReason = CloseError
+	default:
+		summary.CloseReason = CloseCompleted
+	}
+
+	responseCode := Response.SuccessCode
+	if summary.CloseReason == CloseError {
+		responseCode = Response.GeneralError
+	}
+	c.Set(AppResponseCode, responseCode)
+
+	if c.Session != nil {
+		if summary.CloseReason == CloseError {
+			c.Session.SetErrorMessage(err.Error())
+			c.Session.SetResponseCode(Response.GeneralError)
+		} else {
+			c.Session.SetResponseCode(Response.SuccessCode)
+		}
+		c.Session.T4(summary)
+	}
+
+	// the response is committed, errors are only recorded in the TDR
+	return nil
+}
+
+func singleLine(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
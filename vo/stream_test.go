@@ -0,0 +1,189 @@
+package vo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	Logger "github.com/agitdevcenter/gopkg/logger"
+	Response "github.com/agitdevcenter/gopkg/response"
+	Session "github.com/agitdevcenter/gopkg/session"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// tdrRecorder logger keeping the TDRs
+type tdrRecorder struct {
+	Logger.Logger
+	mutex sync.Mutex
+	tdrs  []Logger.LogTdrModel
+}
+
+func (r *tdrRecorder) TDR(tdr Logger.LogTdrModel) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.tdrs = append(r.tdrs, tdr)
+}
+
+func (r *tdrRecorder) recorded() []Logger.LogTdrModel {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Logger.LogTdrModel{}, r.tdrs...)
+}
+
+func newStreamContext(request *http.Request, writer http.ResponseWriter, logger Logger.Logger) *ApplicationContext {
+	c := echo.New().NewContext(request, writer)
+	return &ApplicationContext{Context: c, Session: Session.New(logger)}
+}
+
+func TestSSE(t *testing.T) {
+	logger := &tdrRecorder{Logger: Logger.Noop()}
+	recorder := httptest.NewRecorder()
+	c := newStreamContext(httptest.NewRequest(http.MethodGet, "/events", nil), recorder, logger)
+
+	err := c.SSE(StreamOptions{}, func(stream *Stream) error {
+		assert.NoError(t, stream.Send(Event{ID: "1", Event: "update\nforged", Data: "first\r\nsecond", Retry: 2 * time.Second}))
+		assert.NoError(t, stream.Send(Event{Data: map[string]int{"amount": 100}}))
+		return stream.Send(Event{Event: "ping"})
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, MIMETextEventStream, recorder.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "no-cache", recorder.Header().Get("Cache-Control"))
+	assert.Equal(t, "id: 1\nevent: updateforged\nretry: 2000\ndata: first\ndata: second\n\n"+
+		"data: {\"amount\":100}\n\n"+
+		"event: ping\ndata: \n\n", recorder.Body.String())
+
+	assert.Equal(t, true, c.Get(AlreadyLogged))
+	assert.Equal(t, Response.SuccessCode, c.Get(AppResponseCode))
+
+	tdrs := logger.recorded()
+	if assert.Len(t, tdrs, 1) {
+		summary := tdrs[0].Response.(StreamSummary)
+		assert.Equal(t, 3, summary.Events)
+		assert.Equal(t, int64(recorder.Body.Len()), summary.Bytes)
+		assert.Equal(t, CloseCompleted, summary.CloseReason)
+		assert.Equal(t, Response.SuccessCode, tdrs[0].ResponseCode)
+	}
+}
+
+func TestSSEHeartbeat(t *testing.T) {
+	logger := &tdrRecorder{Logger: Logger.Noop()}
+	recorder := httptest.NewRecorder()
+	c := newStreamContext(httptest.NewRequest(http.MethodGet, "/events", nil), recorder, logger)
+
+	err := c.SSE(StreamOptions{Heartbeat: 10 * time.Millisecond}, func(stream *Stream) error {
+		time.Sleep(55 * time.Millisecond)
+		return stream.Send(Event{Data: "done"})
+	})
+	assert.NoError(t, err)
+
+	body := recorder.Body.String()
+	assert.True(t, strings.Count(body, ": heartbeat\n\n") >= 3, body)
+	assert.True(t, strings.HasSuffix(body, "data: done\n\n"), body)
+
+	tdrs := logger.recorded()
+	if assert.Len(t, tdrs, 1) {
+		// heartbeats are not events
+		assert.Equal(t, 1, tdrs[0].Response.(StreamSummary).Events)
+	}
+}
+
+func TestStreamCloseReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		options StreamOptions
+		fn      func(stream *Stream) error
+		reason  string
+		code    string
+	}{
+		{
+			name:    "timeout",
+			options: StreamOptions{Timeout: 20 * time.Millisecond},
+			fn: func(stream *Stream) error {
+				<-stream.Context().Done()
+				return stream.Send(Event{Data: "late"})
+			},
+			reason: CloseTimeout,
+			code:   Response.SuccessCode,
+		},
+		{
+			name:    "error",
+			options: StreamOptions{},
+			fn: func(stream *Stream) error {
+				return errors.New("upstream failed")
+			},
+			reason: CloseError,
+			code:   Response.GeneralError,
+		},
+	}
+
+	for _, test := range tests {
+		logger := &tdrRecorder{Logger: Logger.Noop()}
+		recorder := httptest.NewRecorder()
+		c := newStreamContext(httptest.NewRequest(http.MethodGet, "/events", nil), recorder, logger)
+
+		assert.NoError(t, c.Stream("application/x-ndjson", test.options, test.fn), test.name)
+		assert.Equal(t, test.code, c.Get(AppResponseCode), test.name)
+
+		tdrs := logger.recorded()
+		if assert.Len(t, tdrs, 1, test.name) {
+			assert.Equal(t, test.reason, tdrs[0].Response.(StreamSummary).CloseReason, test.name)
+			assert.Equal(t, test.code, tdrs[0].ResponseCode, test.name)
+		}
+	}
+}
+
+func TestSSEClientClose(t *testing.T) {
+	logger := &tdrRecorder{Logger: Logger.Noop()}
+	closed := make(chan StreamSummary, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := newStreamContext(r, w, logger)
+		c.SSE(StreamOptions{Heartbeat: 5 * time.Millisecond}, func(stream *Stream) error {
+			for {
+				if err := stream.Send(Event{Data: "tick"}); err != nil {
+					return err
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		})
+
+		tdrs := logger.recorded()
+		if assert.Len(t, tdrs, 1) {
+			closed <- tdrs[0].Response.(StreamSummary)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NoError(t, err)
+
+	response, err := http.DefaultClient.Do(request.WithContext(ctx))
+	if !assert.NoError(t, err) {
+		cancel()
+		return
+	}
+
+	line, err := bufio.NewReader(response.Body).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "data: tick\n", line)
+	cancel()
+	response.Body.Close()
+
+	select {
+	case summary := <-closed:
+		assert.Equal(t, CloseDisconnected, summary.CloseReason)
+		assert.True(t, summary.Events >= 1)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not closed after the client disconnected")
+	}
+}
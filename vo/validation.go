@@ -0,0 +1,73 @@
+package vo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+const HeaderAcceptLanguage = "Accept-Language"
+
+// FieldError a single failed validation rule
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Source  string `json:"source,omitempty" xml:"source,omitempty"`
+	Rule    string `json:"rule" xml:"rule"`
+	Param   string `json:"param,omitempty" xml:"param,omitempty"`
+	Message string `json:"message" xml:"message"`
+}
+
+// FieldValidator implemented by echo validators able to describe failures per field in the requested languages
+type FieldValidator interface {
+	FieldErrors(err error, languages ...string) []FieldError
+}
+
+// FieldErrors untranslated field errors for validators not implementing FieldValidator
+func FieldErrors(err error) (fields []FieldError) {
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return
+	}
+
+	for _, fe := range validationErrors {
+		fields = append(fields, FieldError{
+			Field:   FieldPath(fe.Namespace()),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fmt.Sprintf("validation for '%s' failed on the '%s' tag", FieldPath(fe.Namespace()), fe.Tag()),
+		})
+	}
+	return
+}
+
+// FieldPath strips the top level struct name from a validator namespace
+func FieldPath(namespace string) string {
+	if index := strings.Index(namespace, "."); index >= 0 {
+		return namespace[index+1:]
+	}
+	return namespace
+}
+
+// AcceptLanguages languages of the Accept-Language header by preference, region subtags are followed by their base language
+func AcceptLanguages(request *http.Request) (languages []string) {
+	for _, mediaType := range parseAccept(request.Header.Get(HeaderAcceptLanguage)) {
+		if mediaType == "*" {
+			continue
+		}
+		languages = append(languages, strings.Replace(mediaType, "-", "_", -1))
+		if index := strings.Index(mediaType, "-"); index > 0 {
+			languages = append(languages, mediaType[:index])
+		}
+	}
+	return
+}
+
+func fieldMessages(fields []FieldError) string {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+	return strings.Join(messages, ", ")
+}